@@ -0,0 +1,34 @@
+package ast
+
+import "interp/token"
+
+// Comments are the comments of a file, attached to the nodes they are next
+// to so that tools which print the source back, like the formatter, can
+// keep them.
+type Comments struct {
+	// Leading are the comments on the lines before a statement.
+	Leading map[Stmt][]token.Token
+	// Trailing are the comments inside a statement that don't belong to a
+	// nested statement, and those after it on its last line.
+	Trailing map[Stmt][]token.Token
+	// End are the comments after the last statement of a body. They are
+	// keyed by the *BlockStmt, *FunctionStmt, *LambdaExpr, *CatchClause or
+	// *ClassStmt the body belongs to, and by nil at the end of the file.
+	End map[any][]token.Token
+	// EndSpaced are the bodies, keyed like End, whose end comments are
+	// separated from the last statement by a blank line.
+	EndSpaced map[any]bool
+	// Spaced are the statements separated from the one before them by a
+	// blank line.
+	Spaced map[Stmt]bool
+}
+
+func NewComments() *Comments {
+	return &Comments{
+		Leading:   map[Stmt][]token.Token{},
+		Trailing:  map[Stmt][]token.Token{},
+		End:       map[any][]token.Token{},
+		EndSpaced: map[any]bool{},
+		Spaced:    map[Stmt]bool{},
+	}
+}
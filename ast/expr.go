@@ -14,10 +14,15 @@ type exprVisitor interface {
 	VisitCallExpr(*CallExpr) (any, error)
 	VisitGetExpr(*GetExpr) (any, error)
 	VisitSetExpr(*SetExpr) (any, error)
+	VisitSuperExpr(*SuperExpr) (any, error)
 	VisitThisExpr(*ThisExpr) (any, error)
 	VisitGroupingExpr(*GroupingExpr) (any, error)
+	VisitIndexGetExpr(*IndexGetExpr) (any, error)
+	VisitIndexSetExpr(*IndexSetExpr) (any, error)
 	VisitLambdaExpr(*LambdaExpr) (any, error)
+	VisitListExpr(*ListExpr) (any, error)
 	VisitLiteralExpr(*LiteralExpr) (any, error)
+	VisitMapExpr(*MapExpr) (any, error)
 	VisitUnaryExpr(*UnaryExpr) (any, error)
 	VisitVariableExpr(*VariableExpr) (any, error)
 	VisitAssignExpr(*AssignExpr) (any, error)
@@ -66,36 +71,81 @@ func (g *GetExpr) Accept(visitor exprVisitor) (any, error) {
 }
 
 type GroupingExpr struct {
+	Paren      token.Token
 	Expression Expr
 }
 
-func NewGroupingExpr(expression Expr) *GroupingExpr {
-	return &GroupingExpr{expression}
+func NewGroupingExpr(paren token.Token, expression Expr) *GroupingExpr {
+	return &GroupingExpr{paren, expression}
 }
 
 func (g *GroupingExpr) Accept(visitor exprVisitor) (any, error) {
 	return visitor.VisitGroupingExpr(g)
 }
 
+type IndexGetExpr struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+}
+
+func NewIndexGetExpr(object Expr, bracket token.Token, index Expr) *IndexGetExpr {
+	return &IndexGetExpr{object, bracket, index}
+}
+
+func (i *IndexGetExpr) Accept(visitor exprVisitor) (any, error) {
+	return visitor.VisitIndexGetExpr(i)
+}
+
+type IndexSetExpr struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+	Value   Expr
+}
+
+func NewIndexSetExpr(object Expr, bracket token.Token, index Expr, value Expr) *IndexSetExpr {
+	return &IndexSetExpr{object, bracket, index, value}
+}
+
+func (i *IndexSetExpr) Accept(visitor exprVisitor) (any, error) {
+	return visitor.VisitIndexSetExpr(i)
+}
+
 type LambdaExpr struct {
-	Params []token.Token
-	Body   []Stmt
+	Keyword token.Token
+	Params  []token.Token
+	Body    []Stmt
 }
 
-func NewLambdaExpr(params []token.Token, body []Stmt) *LambdaExpr {
-	return &LambdaExpr{params, body}
+func NewLambdaExpr(keyword token.Token, params []token.Token, body []Stmt) *LambdaExpr {
+	return &LambdaExpr{keyword, params, body}
 }
 
 func (l *LambdaExpr) Accept(visitor exprVisitor) (any, error) {
 	return visitor.VisitLambdaExpr(l)
 }
 
+type ListExpr struct {
+	Bracket  token.Token
+	Elements []Expr
+}
+
+func NewListExpr(bracket token.Token, elements []Expr) *ListExpr {
+	return &ListExpr{bracket, elements}
+}
+
+func (l *ListExpr) Accept(visitor exprVisitor) (any, error) {
+	return visitor.VisitListExpr(l)
+}
+
 type LiteralExpr struct {
+	Token token.Token
 	Value any
 }
 
-func NewLiteralExpr(value any) *LiteralExpr {
-	return &LiteralExpr{value}
+func NewLiteralExpr(token token.Token, value any) *LiteralExpr {
+	return &LiteralExpr{token, value}
 }
 
 func (l *LiteralExpr) Accept(visitor exprVisitor) (any, error) {
@@ -116,6 +166,20 @@ func (l *LogicalExpr) Accept(visitor exprVisitor) (any, error) {
 	return visitor.VisitLogicalExpr(l)
 }
 
+type MapExpr struct {
+	Brace  token.Token
+	Keys   []Expr
+	Values []Expr
+}
+
+func NewMapExpr(brace token.Token, keys []Expr, values []Expr) *MapExpr {
+	return &MapExpr{brace, keys, values}
+}
+
+func (m *MapExpr) Accept(visitor exprVisitor) (any, error) {
+	return visitor.VisitMapExpr(m)
+}
+
 type SetExpr struct {
 	Object Expr
 	Name   token.Token
@@ -130,6 +194,19 @@ func (s *SetExpr) Accept(visitor exprVisitor) (any, error) {
 	return visitor.VisitSetExpr(s)
 }
 
+type SuperExpr struct {
+	Keyword token.Token
+	Method  token.Token
+}
+
+func NewSuperExpr(keyword token.Token, method token.Token) *SuperExpr {
+	return &SuperExpr{keyword, method}
+}
+
+func (s *SuperExpr) Accept(visitor exprVisitor) (any, error) {
+	return visitor.VisitSuperExpr(s)
+}
+
 type ThisExpr struct {
 	Keyword token.Token
 }
@@ -0,0 +1,108 @@
+package ast
+
+// Inspect calls f for every statement and expression in the statements,
+// parents before their children. The node is a Stmt or an Expr; class
+// methods are passed as their *FunctionStmt and catch clauses as their
+// *CatchClause. If f returns false, the children of the node are skipped.
+func Inspect(statements []Stmt, f func(node any) bool) {
+	for _, stmt := range statements {
+		inspectStmt(stmt, f)
+	}
+}
+
+func inspectStmt(stmt Stmt, f func(node any) bool) {
+	if stmt == nil || !f(stmt) {
+		return
+	}
+
+	switch stmt := stmt.(type) {
+	case *ExpressionStmt:
+		inspectExpr(stmt.Expression, f)
+	case *FunctionStmt:
+		Inspect(stmt.Body, f)
+	case *IfStmt:
+		inspectExpr(stmt.Condition, f)
+		inspectStmt(stmt.ThenBranch, f)
+		inspectStmt(stmt.ElseBranch, f)
+	case *PrintStmt:
+		inspectExpr(stmt.Expression, f)
+	case *ReturnStmt:
+		inspectExpr(stmt.Value, f)
+	case *VarStmt:
+		inspectExpr(stmt.Initializer, f)
+	case *WhileStmt:
+		inspectExpr(stmt.Condition, f)
+		inspectStmt(stmt.Body, f)
+		inspectExpr(stmt.Increment, f)
+	case *BlockStmt:
+		Inspect(stmt.Statements, f)
+	case *ClassStmt:
+		if stmt.Superclass != nil {
+			inspectExpr(stmt.Superclass, f)
+		}
+		for _, method := range stmt.Methods {
+			inspectStmt(method, f)
+		}
+	case *ThrowStmt:
+		inspectExpr(stmt.Value, f)
+	case *TryStmt:
+		inspectStmt(stmt.Body, f)
+		if stmt.Catch != nil && f(stmt.Catch) {
+			Inspect(stmt.Catch.Body, f)
+		}
+		if stmt.Finally != nil {
+			inspectStmt(stmt.Finally, f)
+		}
+	case *ExportStmt:
+		inspectStmt(stmt.Declaration, f)
+	}
+}
+
+func inspectExpr(expr Expr, f func(node any) bool) {
+	if expr == nil || !f(expr) {
+		return
+	}
+
+	switch expr := expr.(type) {
+	case *BinaryExpr:
+		inspectExpr(expr.Left, f)
+		inspectExpr(expr.Right, f)
+	case *CallExpr:
+		inspectExpr(expr.Callee, f)
+		for _, argument := range expr.Arguments {
+			inspectExpr(argument, f)
+		}
+	case *GetExpr:
+		inspectExpr(expr.Object, f)
+	case *GroupingExpr:
+		inspectExpr(expr.Expression, f)
+	case *IndexGetExpr:
+		inspectExpr(expr.Object, f)
+		inspectExpr(expr.Index, f)
+	case *IndexSetExpr:
+		inspectExpr(expr.Object, f)
+		inspectExpr(expr.Index, f)
+		inspectExpr(expr.Value, f)
+	case *LambdaExpr:
+		Inspect(expr.Body, f)
+	case *ListExpr:
+		for _, element := range expr.Elements {
+			inspectExpr(element, f)
+		}
+	case *LogicalExpr:
+		inspectExpr(expr.Left, f)
+		inspectExpr(expr.Right, f)
+	case *MapExpr:
+		for i := range expr.Keys {
+			inspectExpr(expr.Keys[i], f)
+			inspectExpr(expr.Values[i], f)
+		}
+	case *SetExpr:
+		inspectExpr(expr.Object, f)
+		inspectExpr(expr.Value, f)
+	case *UnaryExpr:
+		inspectExpr(expr.Right, f)
+	case *AssignExpr:
+		inspectExpr(expr.Value, f)
+	}
+}
@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"interp/token"
+)
+
+// StmtStart returns the token that the statement starts at, or for function,
+// variable and class declarations the name they declare.
+func StmtStart(stmt Stmt) token.Token {
+	switch stmt := stmt.(type) {
+	case *ExpressionStmt:
+		return ExprStart(stmt.Expression)
+	case *FunctionStmt:
+		return stmt.Name
+	case *IfStmt:
+		return stmt.Keyword
+	case *PrintStmt:
+		return stmt.Keyword
+	case *ReturnStmt:
+		return stmt.Keyword
+	case *VarStmt:
+		return stmt.Name
+	case *WhileStmt:
+		return stmt.Keyword
+	case *BlockStmt:
+		return stmt.Brace
+	case *ClassStmt:
+		return stmt.Name
+	case *BreakStmt:
+		return stmt.Keyword
+	case *ContinueStmt:
+		return stmt.Keyword
+	case *ThrowStmt:
+		return stmt.Keyword
+	case *TryStmt:
+		return stmt.Keyword
+	case *ImportStmt:
+		return stmt.Keyword
+	case *ExportStmt:
+		return stmt.Keyword
+	}
+	return token.Token{}
+}
+
+// ExprStart returns the token that the expression starts at.
+func ExprStart(expr Expr) token.Token {
+	switch expr := expr.(type) {
+	case *BinaryExpr:
+		return ExprStart(expr.Left)
+	case *CallExpr:
+		return ExprStart(expr.Callee)
+	case *GetExpr:
+		return ExprStart(expr.Object)
+	case *GroupingExpr:
+		return expr.Paren
+	case *IndexGetExpr:
+		return ExprStart(expr.Object)
+	case *IndexSetExpr:
+		return ExprStart(expr.Object)
+	case *LambdaExpr:
+		return expr.Keyword
+	case *ListExpr:
+		return expr.Bracket
+	case *LiteralExpr:
+		return expr.Token
+	case *LogicalExpr:
+		return ExprStart(expr.Left)
+	case *MapExpr:
+		return expr.Brace
+	case *SetExpr:
+		return ExprStart(expr.Object)
+	case *SuperExpr:
+		return expr.Keyword
+	case *ThisExpr:
+		return expr.Keyword
+	case *UnaryExpr:
+		return expr.Operator
+	case *VariableExpr:
+		return expr.Name
+	case *AssignExpr:
+		return expr.Name
+	}
+	return token.Token{}
+}
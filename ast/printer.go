@@ -0,0 +1,459 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"interp/token"
+	"strconv"
+	"strings"
+)
+
+// Node is a node of the syntax tree in a form that prints as an S-expression
+// and encodes to JSON. Its position is where it starts, as given by StmtStart
+// and ExprStart. Lines and columns start at 1.
+type Node struct {
+	Type   string
+	Line   int
+	Column int
+	Fields []Field
+}
+
+// Field is a field of a node, named like the field of the syntax tree node
+// but starting in lower case. The value is a *Node, a []*Node, a token.Token,
+// a *token.Token, a []token.Token or the value of a literal.
+type Field struct {
+	Name  string
+	Value any
+}
+
+// Nodes converts statements to nodes. For loops show as the while loops
+// the parser turns them into.
+func Nodes(statements []Stmt) []*Node {
+	return printer{}.stmts(statements)
+}
+
+// ExprNode converts an expression to a node.
+func ExprNode(expr Expr) *Node {
+	return printer{}.expr(expr)
+}
+
+// Sexpr prints statements as S-expressions, one per line or more.
+func Sexpr(statements []Stmt) string {
+	var out strings.Builder
+	for _, node := range Nodes(statements) {
+		out.WriteString(node.String())
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// printer converts the nodes of the syntax tree to Nodes as it visits them.
+type printer struct{}
+
+func (p printer) stmt(stmt Stmt) *Node {
+	if stmt == nil {
+		return nil
+	}
+	node, _ := stmt.Accept(p)
+	return node.(*Node)
+}
+
+func (p printer) stmts(statements []Stmt) []*Node {
+	nodes := make([]*Node, len(statements))
+	for i, stmt := range statements {
+		nodes[i] = p.stmt(stmt)
+	}
+	return nodes
+}
+
+func (p printer) expr(expr Expr) *Node {
+	if expr == nil {
+		return nil
+	}
+	node, _ := expr.Accept(p)
+	return node.(*Node)
+}
+
+func (p printer) exprs(exprs []Expr) []*Node {
+	nodes := make([]*Node, len(exprs))
+	for i, expr := range exprs {
+		nodes[i] = p.expr(expr)
+	}
+	return nodes
+}
+
+func (p printer) function(stmt *FunctionStmt) *Node {
+	function, _ := node("FunctionStmt", stmt.Name,
+		Field{"name", stmt.Name},
+		Field{"params", stmt.Params},
+		Field{"body", p.stmts(stmt.Body)})
+	return function
+}
+
+func (p printer) block(stmt *BlockStmt) *Node {
+	if stmt == nil {
+		return nil
+	}
+	block, _ := node("BlockStmt", stmt.Brace,
+		Field{"brace", stmt.Brace},
+		Field{"statements", p.stmts(stmt.Statements)})
+	return block
+}
+
+// node makes a node at the token, returning it the way visitors return their
+// results.
+func node(name string, at token.Token, fields ...Field) (*Node, error) {
+	return &Node{name, at.Line, at.Column + 1, fields}, nil
+}
+
+func (p printer) VisitExpressionStmt(stmt *ExpressionStmt) (any, error) {
+	return node("ExpressionStmt", StmtStart(stmt),
+		Field{"expression", p.expr(stmt.Expression)})
+}
+
+func (p printer) VisitFunctionStmt(stmt *FunctionStmt) (any, error) {
+	return p.function(stmt), nil
+}
+
+func (p printer) VisitPrintStmt(stmt *PrintStmt) (any, error) {
+	return node("PrintStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"expression", p.expr(stmt.Expression)})
+}
+
+func (p printer) VisitReturnStmt(stmt *ReturnStmt) (any, error) {
+	return node("ReturnStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"value", p.expr(stmt.Value)})
+}
+
+func (p printer) VisitVarStmt(stmt *VarStmt) (any, error) {
+	return node("VarStmt", stmt.Name,
+		Field{"name", stmt.Name},
+		Field{"initializer", p.expr(stmt.Initializer)})
+}
+
+func (p printer) VisitWhileStmt(stmt *WhileStmt) (any, error) {
+	return node("WhileStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"condition", p.expr(stmt.Condition)},
+		Field{"body", p.stmt(stmt.Body)},
+		Field{"increment", p.expr(stmt.Increment)})
+}
+
+func (p printer) VisitBlockStmt(stmt *BlockStmt) (any, error) {
+	return p.block(stmt), nil
+}
+
+func (p printer) VisitClassStmt(stmt *ClassStmt) (any, error) {
+	var superclass *Node
+	if stmt.Superclass != nil {
+		superclass = p.expr(stmt.Superclass)
+	}
+	methods := make([]*Node, len(stmt.Methods))
+	for i, method := range stmt.Methods {
+		methods[i] = p.function(method)
+	}
+	return node("ClassStmt", stmt.Name,
+		Field{"name", stmt.Name},
+		Field{"superclass", superclass},
+		Field{"methods", methods})
+}
+
+func (p printer) VisitIfStmt(stmt *IfStmt) (any, error) {
+	return node("IfStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"condition", p.expr(stmt.Condition)},
+		Field{"thenBranch", p.stmt(stmt.ThenBranch)},
+		Field{"elseBranch", p.stmt(stmt.ElseBranch)})
+}
+
+func (p printer) VisitBreakStmt(stmt *BreakStmt) (any, error) {
+	return node("BreakStmt", stmt.Keyword, Field{"keyword", stmt.Keyword})
+}
+
+func (p printer) VisitContinueStmt(stmt *ContinueStmt) (any, error) {
+	return node("ContinueStmt", stmt.Keyword, Field{"keyword", stmt.Keyword})
+}
+
+func (p printer) VisitThrowStmt(stmt *ThrowStmt) (any, error) {
+	return node("ThrowStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"value", p.expr(stmt.Value)})
+}
+
+func (p printer) VisitTryStmt(stmt *TryStmt) (any, error) {
+	var catch *Node
+	if stmt.Catch != nil {
+		catch, _ = node("CatchClause", stmt.Catch.Name,
+			Field{"name", stmt.Catch.Name},
+			Field{"body", p.stmts(stmt.Catch.Body)})
+	}
+	return node("TryStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"body", p.block(stmt.Body)},
+		Field{"catch", catch},
+		Field{"finally", p.block(stmt.Finally)})
+}
+
+func (p printer) VisitImportStmt(stmt *ImportStmt) (any, error) {
+	return node("ImportStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"path", stmt.Path},
+		Field{"alias", stmt.Alias},
+		Field{"names", stmt.Names})
+}
+
+func (p printer) VisitExportStmt(stmt *ExportStmt) (any, error) {
+	return node("ExportStmt", stmt.Keyword,
+		Field{"keyword", stmt.Keyword},
+		Field{"declaration", p.stmt(stmt.Declaration)})
+}
+
+func (p printer) VisitBinaryExpr(expr *BinaryExpr) (any, error) {
+	return node("BinaryExpr", ExprStart(expr),
+		Field{"left", p.expr(expr.Left)},
+		Field{"operator", expr.Operator},
+		Field{"right", p.expr(expr.Right)})
+}
+
+func (p printer) VisitCallExpr(expr *CallExpr) (any, error) {
+	return node("CallExpr", ExprStart(expr),
+		Field{"callee", p.expr(expr.Callee)},
+		Field{"paren", expr.Paren},
+		Field{"arguments", p.exprs(expr.Arguments)})
+}
+
+func (p printer) VisitGetExpr(expr *GetExpr) (any, error) {
+	return node("GetExpr", ExprStart(expr),
+		Field{"object", p.expr(expr.Object)},
+		Field{"name", expr.Name})
+}
+
+func (p printer) VisitSetExpr(expr *SetExpr) (any, error) {
+	return node("SetExpr", ExprStart(expr),
+		Field{"object", p.expr(expr.Object)},
+		Field{"name", expr.Name},
+		Field{"value", p.expr(expr.Value)})
+}
+
+func (p printer) VisitSuperExpr(expr *SuperExpr) (any, error) {
+	return node("SuperExpr", expr.Keyword,
+		Field{"keyword", expr.Keyword},
+		Field{"method", expr.Method})
+}
+
+func (p printer) VisitThisExpr(expr *ThisExpr) (any, error) {
+	return node("ThisExpr", expr.Keyword, Field{"keyword", expr.Keyword})
+}
+
+func (p printer) VisitGroupingExpr(expr *GroupingExpr) (any, error) {
+	return node("GroupingExpr", expr.Paren,
+		Field{"paren", expr.Paren},
+		Field{"expression", p.expr(expr.Expression)})
+}
+
+func (p printer) VisitIndexGetExpr(expr *IndexGetExpr) (any, error) {
+	return node("IndexGetExpr", ExprStart(expr),
+		Field{"object", p.expr(expr.Object)},
+		Field{"bracket", expr.Bracket},
+		Field{"index", p.expr(expr.Index)})
+}
+
+func (p printer) VisitIndexSetExpr(expr *IndexSetExpr) (any, error) {
+	return node("IndexSetExpr", ExprStart(expr),
+		Field{"object", p.expr(expr.Object)},
+		Field{"bracket", expr.Bracket},
+		Field{"index", p.expr(expr.Index)},
+		Field{"value", p.expr(expr.Value)})
+}
+
+func (p printer) VisitLambdaExpr(expr *LambdaExpr) (any, error) {
+	return node("LambdaExpr", expr.Keyword,
+		Field{"keyword", expr.Keyword},
+		Field{"params", expr.Params},
+		Field{"body", p.stmts(expr.Body)})
+}
+
+func (p printer) VisitListExpr(expr *ListExpr) (any, error) {
+	return node("ListExpr", expr.Bracket,
+		Field{"bracket", expr.Bracket},
+		Field{"elements", p.exprs(expr.Elements)})
+}
+
+func (p printer) VisitLiteralExpr(expr *LiteralExpr) (any, error) {
+	return node("LiteralExpr", expr.Token,
+		Field{"token", expr.Token},
+		Field{"value", expr.Value})
+}
+
+func (p printer) VisitMapExpr(expr *MapExpr) (any, error) {
+	return node("MapExpr", expr.Brace,
+		Field{"brace", expr.Brace},
+		Field{"keys", p.exprs(expr.Keys)},
+		Field{"values", p.exprs(expr.Values)})
+}
+
+func (p printer) VisitUnaryExpr(expr *UnaryExpr) (any, error) {
+	return node("UnaryExpr", expr.Operator,
+		Field{"operator", expr.Operator},
+		Field{"right", p.expr(expr.Right)})
+}
+
+func (p printer) VisitVariableExpr(expr *VariableExpr) (any, error) {
+	return node("VariableExpr", expr.Name, Field{"name", expr.Name})
+}
+
+func (p printer) VisitLogicalExpr(expr *LogicalExpr) (any, error) {
+	return node("LogicalExpr", ExprStart(expr),
+		Field{"left", p.expr(expr.Left)},
+		Field{"operator", expr.Operator},
+		Field{"right", p.expr(expr.Right)})
+}
+
+func (p printer) VisitAssignExpr(expr *AssignExpr) (any, error) {
+	return node("AssignExpr", expr.Name,
+		Field{"name", expr.Name},
+		Field{"value", p.expr(expr.Value)})
+}
+
+// String prints the node as an indented S-expression. Tokens, values and
+// missing nodes are written first, inline as name=value; then the nodes each
+// go on a line of their own.
+func (n *Node) String() string {
+	var out strings.Builder
+	n.write(&out, 0)
+	return out.String()
+}
+
+func (n *Node) write(out *strings.Builder, indent int) {
+	if n == nil {
+		out.WriteString("nil")
+		return
+	}
+
+	fmt.Fprintf(out, "(%s @%d:%d", n.Type, n.Line, n.Column)
+	var children []Field
+	for _, field := range n.Fields {
+		switch value := field.Value.(type) {
+		case *Node:
+			if value != nil {
+				children = append(children, field)
+				continue
+			}
+		case []*Node:
+			if len(value) > 0 {
+				children = append(children, field)
+				continue
+			}
+		}
+		fmt.Fprintf(out, " %s=%s", field.Name, inline(field.Value))
+	}
+
+	prefix := "\n" + strings.Repeat("  ", indent+1)
+	for _, field := range children {
+		out.WriteString(prefix + field.Name + ": ")
+		switch value := field.Value.(type) {
+		case *Node:
+			value.write(out, indent+1)
+		case []*Node:
+			out.WriteString("[")
+			for _, node := range value {
+				out.WriteString(prefix + "  ")
+				node.write(out, indent+2)
+			}
+			out.WriteString("]")
+		}
+	}
+	out.WriteString(")")
+}
+
+// inline formats a token or a value to fit on the line of its node.
+func inline(value any) string {
+	switch value := value.(type) {
+	case token.Token:
+		return lexeme(value)
+	case *token.Token:
+		if value == nil {
+			return "nil"
+		}
+		return lexeme(*value)
+	case []token.Token:
+		lexemes := make([]string, len(value))
+		for i, token := range value {
+			lexemes[i] = lexeme(token)
+		}
+		return "[" + strings.Join(lexemes, " ") + "]"
+	case *Node:
+		// Only missing nodes and empty lists of them are written inline.
+		return "nil"
+	case []*Node:
+		return "[]"
+	case string:
+		return strconv.Quote(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case nil:
+		return "nil"
+	}
+	return fmt.Sprint(value)
+}
+
+// lexeme quotes lexemes that would make the S-expression ambiguous.
+func lexeme(token token.Token) string {
+	if token.Lexeme == "" || strings.ContainsAny(token.Lexeme, " \t\r\n()[]=\"") {
+		return strconv.Quote(token.Lexeme)
+	}
+	return token.Lexeme
+}
+
+// MarshalJSON encodes the node as an object with its type and position,
+// followed by its fields in order. Tokens are objects with their type,
+// lexeme and position.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, `{"type":%q,"line":%d,"column":%d`, n.Type, n.Line, n.Column)
+	for _, field := range n.Fields {
+		value, err := json.Marshal(jsonValue(field.Value))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, ",%q:%s", field.Name, value)
+	}
+	out.WriteString("}")
+	return out.Bytes(), nil
+}
+
+type jsonToken struct {
+	Type   token.TokenType `json:"type"`
+	Lexeme string          `json:"lexeme"`
+	Line   int             `json:"line"`
+	Column int             `json:"column"`
+}
+
+func jsonValue(value any) any {
+	switch value := value.(type) {
+	case *Node:
+		if value == nil {
+			return nil
+		}
+		return value
+	case token.Token:
+		return jsonToken{value.Type, value.Lexeme, value.Line, value.Column + 1}
+	case *token.Token:
+		if value == nil {
+			return nil
+		}
+		return jsonValue(*value)
+	case []token.Token:
+		tokens := make([]any, len(value))
+		for i, token := range value {
+			tokens[i] = jsonValue(token)
+		}
+		return tokens
+	}
+	return value
+}
@@ -19,6 +19,11 @@ type stmtVisitor interface {
 	VisitClassStmt(*ClassStmt) (any, error)
 	VisitIfStmt(*IfStmt) (any, error)
 	VisitBreakStmt(*BreakStmt) (any, error)
+	VisitContinueStmt(*ContinueStmt) (any, error)
+	VisitThrowStmt(*ThrowStmt) (any, error)
+	VisitTryStmt(*TryStmt) (any, error)
+	VisitImportStmt(*ImportStmt) (any, error)
+	VisitExportStmt(*ExportStmt) (any, error)
 }
 
 type ExpressionStmt struct {
@@ -48,13 +53,14 @@ func (f *FunctionStmt) Accept(visitor stmtVisitor) (any, error) {
 }
 
 type IfStmt struct {
+	Keyword    token.Token
 	Condition  Expr
 	ThenBranch Stmt
 	ElseBranch Stmt
 }
 
-func NewIfStmt(condition Expr, thenBranch Stmt, elseBranch Stmt) *IfStmt {
-	return &IfStmt{condition, thenBranch, elseBranch}
+func NewIfStmt(keyword token.Token, condition Expr, thenBranch Stmt, elseBranch Stmt) *IfStmt {
+	return &IfStmt{keyword, condition, thenBranch, elseBranch}
 }
 
 func (i *IfStmt) Accept(visitor stmtVisitor) (any, error) {
@@ -62,11 +68,12 @@ func (i *IfStmt) Accept(visitor stmtVisitor) (any, error) {
 }
 
 type PrintStmt struct {
+	Keyword    token.Token
 	Expression Expr
 }
 
-func NewPrintStmt(expression Expr) *PrintStmt {
-	return &PrintStmt{expression}
+func NewPrintStmt(keyword token.Token, expression Expr) *PrintStmt {
+	return &PrintStmt{keyword, expression}
 }
 
 func (p *PrintStmt) Accept(visitor stmtVisitor) (any, error) {
@@ -99,25 +106,33 @@ func (v *VarStmt) Accept(visitor stmtVisitor) (any, error) {
 	return visitor.VisitVarStmt(v)
 }
 
+// WhileStmt is a while loop. For loops are desugared into a WhileStmt with
+// an Increment, which runs after every iteration of the body, including ones
+// that ended with a continue statement. Keyword is the 'while' or 'for'.
 type WhileStmt struct {
+	Keyword   token.Token
 	Condition Expr
 	Body      Stmt
+	Increment Expr
 }
 
-func NewWhileStmt(condition Expr, body Stmt) *WhileStmt {
-	return &WhileStmt{condition, body}
+func NewWhileStmt(keyword token.Token, condition Expr, body Stmt, increment Expr) *WhileStmt {
+	return &WhileStmt{keyword, condition, body, increment}
 }
 
 func (w *WhileStmt) Accept(visitor stmtVisitor) (any, error) {
 	return visitor.VisitWhileStmt(w)
 }
 
+// BlockStmt is a block. Brace is its '{', or the 'for' of a desugared for
+// loop.
 type BlockStmt struct {
+	Brace      token.Token
 	Statements []Stmt
 }
 
-func NewBlockStmt(statements []Stmt) *BlockStmt {
-	return &BlockStmt{statements}
+func NewBlockStmt(brace token.Token, statements []Stmt) *BlockStmt {
+	return &BlockStmt{brace, statements}
 }
 
 func (b *BlockStmt) Accept(visitor stmtVisitor) (any, error) {
@@ -125,12 +140,13 @@ func (b *BlockStmt) Accept(visitor stmtVisitor) (any, error) {
 }
 
 type ClassStmt struct {
-	Name    token.Token
-	Methods []*FunctionStmt
+	Name       token.Token
+	Superclass *VariableExpr
+	Methods    []*FunctionStmt
 }
 
-func NewClassStmt(name token.Token, methods []*FunctionStmt) *ClassStmt {
-	return &ClassStmt{name, methods}
+func NewClassStmt(name token.Token, superclass *VariableExpr, methods []*FunctionStmt) *ClassStmt {
+	return &ClassStmt{name, superclass, methods}
 }
 
 func (c *ClassStmt) Accept(visitor stmtVisitor) (any, error) {
@@ -148,3 +164,100 @@ func NewBreakStmt(keyword token.Token) *BreakStmt {
 func (b *BreakStmt) Accept(visitor stmtVisitor) (any, error) {
 	return visitor.VisitBreakStmt(b)
 }
+
+type ContinueStmt struct {
+	Keyword token.Token
+}
+
+func NewContinueStmt(keyword token.Token) *ContinueStmt {
+	return &ContinueStmt{keyword}
+}
+
+func (c *ContinueStmt) Accept(visitor stmtVisitor) (any, error) {
+	return visitor.VisitContinueStmt(c)
+}
+
+type ThrowStmt struct {
+	Keyword token.Token
+	Value   Expr
+}
+
+func NewThrowStmt(keyword token.Token, value Expr) *ThrowStmt {
+	return &ThrowStmt{keyword, value}
+}
+
+func (t *ThrowStmt) Accept(visitor stmtVisitor) (any, error) {
+	return visitor.VisitThrowStmt(t)
+}
+
+// TryStmt runs Body, and Catch if Body throws. Finally runs however the two
+// are left. Either Catch or Finally may be nil, but not both.
+type TryStmt struct {
+	Keyword token.Token
+	Body    *BlockStmt
+	Catch   *CatchClause
+	Finally *BlockStmt
+}
+
+func NewTryStmt(keyword token.Token, body *BlockStmt, catch *CatchClause, finally *BlockStmt) *TryStmt {
+	return &TryStmt{keyword, body, catch, finally}
+}
+
+func (t *TryStmt) Accept(visitor stmtVisitor) (any, error) {
+	return visitor.VisitTryStmt(t)
+}
+
+// CatchClause binds the thrown value to Name, which is in scope in Body.
+type CatchClause struct {
+	Name token.Token
+	Body []Stmt
+}
+
+func NewCatchClause(name token.Token, body []Stmt) *CatchClause {
+	return &CatchClause{name, body}
+}
+
+// ImportStmt imports the module at Path, either as a whole under Alias, or
+// only the given Names. Exactly one of the two is set.
+type ImportStmt struct {
+	Keyword token.Token
+	Path    token.Token
+	Alias   *token.Token
+	Names   []token.Token
+}
+
+func NewImportStmt(keyword token.Token, path token.Token, alias *token.Token, names []token.Token) *ImportStmt {
+	return &ImportStmt{keyword, path, alias, names}
+}
+
+func (i *ImportStmt) Accept(visitor stmtVisitor) (any, error) {
+	return visitor.VisitImportStmt(i)
+}
+
+// ExportStmt makes a top-level function, variable or class declaration
+// visible to the scripts that import the module.
+type ExportStmt struct {
+	Keyword     token.Token
+	Declaration Stmt
+}
+
+func NewExportStmt(keyword token.Token, declaration Stmt) *ExportStmt {
+	return &ExportStmt{keyword, declaration}
+}
+
+func (e *ExportStmt) Accept(visitor stmtVisitor) (any, error) {
+	return visitor.VisitExportStmt(e)
+}
+
+// Name returns the name of the exported declaration.
+func (e *ExportStmt) Name() token.Token {
+	switch declaration := e.Declaration.(type) {
+	case *FunctionStmt:
+		return declaration.Name
+	case *VarStmt:
+		return declaration.Name
+	case *ClassStmt:
+		return declaration.Name
+	}
+	return e.Keyword
+}
@@ -0,0 +1,137 @@
+package bytecode
+
+import (
+	"bytes"
+	"interp/ast"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/parser"
+	"interp/resolver"
+	"interp/scanner"
+	"io"
+	"testing"
+)
+
+// The benchmarks run each program on both backends, so that they can be
+// compared with e.g. go test -bench . ./bytecode.
+var benchmarks = []struct {
+	name   string
+	source string
+}{
+	{"Fib", `
+fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 1) + fib(n - 2);
+}
+print fib(20);
+`},
+	{"Loop", `
+var sum = 0;
+for (var i = 0; i < 100000; i = i + 1) {
+  if (i / 2 == floor(i / 2)) continue;
+  sum = sum + i;
+}
+print sum;
+`},
+	{"Closures", `
+fun counter() {
+  var count = 0;
+  return fun () { count = count + 1; return count; };
+}
+var total = 0;
+for (var i = 0; i < 2000; i = i + 1) {
+  var next = counter();
+  for (var j = 0; j < 10; j = j + 1) next();
+  total = total + next();
+}
+print total;
+`},
+	{"Methods", `
+class Vector {
+  init(x, y) { this.x = x; this.y = y; }
+  add(other) { return Vector(this.x + other.x, this.y + other.y); }
+}
+class Scaled < Vector {
+  add(other) { return super.add(Vector(other.x * 2, other.y * 2)); }
+}
+var v = Scaled(0, 0);
+for (var i = 0; i < 10000; i = i + 1) v = Scaled(0, 0).add(Vector(v.x, 1));
+print v.y;
+`},
+	{"Strings", `
+var parts = [];
+for (var i = 0; i < 2000; i = i + 1) parts.push("item " + str(i));
+var text = join(parts, ",");
+print len(split(text, ","));
+`},
+}
+
+func parse(tb testing.TB, source string) []ast.Stmt {
+	diagnostics := errors.NewDiagnostics("")
+	tokens, _ := scanner.NewScanner(source, diagnostics).ScanTokens()
+	par := parser.NewParser(tokens, diagnostics)
+	statements, _ := par.Parse()
+	if diagnostics.HasErrors() {
+		tb.Fatal(diagnostics.Items())
+	}
+	return statements
+}
+
+func runTree(tb testing.TB, statements []ast.Stmt, output io.Writer) {
+	inter := interpreter.NewInterpreter()
+	inter.SetOutput(output)
+	res := resolver.NewResolver(&inter, errors.NewDiagnostics(""))
+	if err := res.Resolve(statements); err != nil {
+		tb.Fatal(err)
+	}
+	if err := inter.Interpret(statements); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func runVM(tb testing.TB, statements []ast.Stmt, output io.Writer) {
+	function := Compile(statements, errors.NewDiagnostics(""))
+	vm := NewVM()
+	vm.SetOutput(output)
+	if err := vm.Run(function); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// TestBenchmarks checks that the backends print the same for the benchmarks,
+// so that they compare the same work.
+func TestBenchmarks(t *testing.T) {
+	for _, benchmark := range benchmarks {
+		t.Run(benchmark.name, func(t *testing.T) {
+			statements := parse(t, benchmark.source)
+			var tree, vm bytes.Buffer
+			runTree(t, statements, &tree)
+			runVM(t, statements, &vm)
+			if tree.Len() == 0 || tree.String() != vm.String() {
+				t.Errorf("tree printed %q, vm %q", tree.String(), vm.String())
+			}
+		})
+	}
+}
+
+func BenchmarkTree(b *testing.B) {
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			statements := parse(b, benchmark.source)
+			for i := 0; i < b.N; i++ {
+				runTree(b, statements, io.Discard)
+			}
+		})
+	}
+}
+
+func BenchmarkVM(b *testing.B) {
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			statements := parse(b, benchmark.source)
+			for i := 0; i < b.N; i++ {
+				runVM(b, statements, io.Discard)
+			}
+		})
+	}
+}
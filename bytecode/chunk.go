@@ -0,0 +1,53 @@
+package bytecode
+
+import "interp/token"
+
+// position is the place in the source that an instruction was compiled from.
+// Runtime errors raised by the instruction are reported there.
+type position struct {
+	line   int
+	column int
+}
+
+// Chunk is the compiled code of a function.
+type Chunk struct {
+	Code      []byte
+	Constants []any
+
+	positions []position
+	constants map[any]int
+}
+
+func (c *Chunk) write(b byte, token token.Token) {
+	c.Code = append(c.Code, b)
+	c.positions = append(c.positions, position{token.Line, token.Column})
+}
+
+// addConstant adds the value to the constant pool and returns its index.
+// Numbers and strings are only added once.
+func (c *Chunk) addConstant(value any) int {
+	switch value.(type) {
+	case int64, float64, string:
+		if index, ok := c.constants[value]; ok {
+			return index
+		}
+	}
+
+	c.Constants = append(c.Constants, value)
+	index := len(c.Constants) - 1
+
+	switch value.(type) {
+	case int64, float64, string:
+		if c.constants == nil {
+			c.constants = map[any]int{}
+		}
+		c.constants[value] = index
+	}
+	return index
+}
+
+// token returns a token pointing at the source of the instruction at offset.
+func (c *Chunk) token(offset int) token.Token {
+	position := c.positions[offset]
+	return token.Token{Line: position.line, Column: position.column}
+}
@@ -0,0 +1,814 @@
+package bytecode
+
+import (
+	"interp/ast"
+	"interp/errors"
+	"interp/token"
+	"math"
+)
+
+type functionKind int
+
+const (
+	kindScript functionKind = iota
+	kindFunction
+	kindLambda
+	kindMethod
+	kindInitializer
+)
+
+const maxLocals = math.MaxUint8 + 1
+
+type local struct {
+	name     string
+	depth    int
+	captured bool
+}
+
+type upvalue struct {
+	index   byte
+	isLocal bool
+}
+
+// loop tracks the jumps out of the innermost loop that still have to be
+// patched once the loop has been compiled.
+type loop struct {
+	enclosing *loop
+	depth     int
+	breaks    []int
+	continues []int
+	// try is the innermost try block around the loop.
+	try *tryBlock
+}
+
+// tryBlock is a part of a try statement that has a handler installed: the
+// try block itself, or the catch block if there is a finally block. Jumps out
+// of it have to remove the handler and run the finally block.
+type tryBlock struct {
+	enclosing *tryBlock
+	finally   *ast.BlockStmt
+	depth     int
+	// locals is the number of locals outside the try statement, plus the slot
+	// that return values are kept in while the finally block runs.
+	locals int
+}
+
+// Compiler compiles the statements of one function. Functions nested in it
+// are compiled by compilers that point back to it, so that variables of the
+// enclosing functions can be captured.
+type Compiler struct {
+	enclosing   *Compiler
+	function    *Function
+	kind        functionKind
+	locals      []local
+	upvalues    []upvalue
+	depth       int
+	loop        *loop
+	try         *tryBlock
+	diagnostics *errors.Diagnostics
+
+	// token is the token that emitted instructions are attributed to.
+	token token.Token
+}
+
+// Compile compiles a resolved script into the function that runs it. Errors
+// are reported to the diagnostics.
+func Compile(statements []ast.Stmt, diagnostics *errors.Diagnostics) *Function {
+	compiler := newCompiler(nil, kindScript, "script", diagnostics)
+	compiler.statements(statements)
+	return compiler.end()
+}
+
+func newCompiler(enclosing *Compiler, kind functionKind, name string, diagnostics *errors.Diagnostics) *Compiler {
+	c := &Compiler{
+		enclosing:   enclosing,
+		function:    &Function{Name: name, lambda: kind == kindLambda},
+		kind:        kind,
+		diagnostics: diagnostics,
+	}
+	if enclosing != nil {
+		c.token = enclosing.token
+	}
+
+	// Slot 0 holds the called closure, or the receiver in methods.
+	slot := local{depth: 0}
+	if kind == kindMethod || kind == kindInitializer {
+		slot.name = "this"
+	}
+	c.locals = append(c.locals, slot)
+	return c
+}
+
+func (c *Compiler) end() *Function {
+	c.emitReturn()
+	c.function.UpvalueCount = len(c.upvalues)
+	return c.function
+}
+
+func (c *Compiler) error(token token.Token, message string) {
+	c.diagnostics.Error(token, message)
+}
+
+func (c *Compiler) statements(statements []ast.Stmt) {
+	for _, statement := range statements {
+		_, _ = statement.Accept(c)
+	}
+}
+
+func (c *Compiler) expression(expr ast.Expr) {
+	_, _ = expr.Accept(c)
+}
+
+func (c *Compiler) at(token token.Token) {
+	c.token = token
+}
+
+// Emitting
+
+func (c *Compiler) chunk() *Chunk {
+	return &c.function.Chunk
+}
+
+func (c *Compiler) emit(bytes ...byte) {
+	for _, b := range bytes {
+		c.chunk().write(b, c.token)
+	}
+}
+
+func (c *Compiler) emitOp(op OpCode, operands ...byte) {
+	c.emit(byte(op))
+	c.emit(operands...)
+}
+
+func (c *Compiler) emitShort(op OpCode, operand int) {
+	c.emit(byte(op), byte(operand>>8), byte(operand))
+}
+
+func (c *Compiler) emitConstant(value any) {
+	c.emitShort(OpConstant, c.makeConstant(value))
+}
+
+func (c *Compiler) makeConstant(value any) int {
+	index := c.chunk().addConstant(value)
+	if index > math.MaxUint16 {
+		c.error(c.token, "Too many constants in one chunk.")
+		return 0
+	}
+	return index
+}
+
+func (c *Compiler) emitJump(op OpCode) int {
+	c.emit(byte(op), 0xff, 0xff)
+	return len(c.chunk().Code) - 2
+}
+
+func (c *Compiler) patchJump(offset int) {
+	jump := len(c.chunk().Code) - offset - 2
+	if jump > math.MaxUint16 {
+		c.error(c.token, "Too much code to jump over.")
+	}
+	c.chunk().Code[offset] = byte(jump >> 8)
+	c.chunk().Code[offset+1] = byte(jump)
+}
+
+func (c *Compiler) emitLoop(start int) {
+	offset := len(c.chunk().Code) - start + 3
+	if offset > math.MaxUint16 {
+		c.error(c.token, "Loop body too large.")
+	}
+	c.emitShort(OpLoop, offset)
+}
+
+func (c *Compiler) emitReturn() {
+	if c.kind == kindInitializer {
+		c.emitOp(OpGetLocal, 0)
+	} else {
+		c.emitOp(OpNil)
+	}
+	c.emitOp(OpReturn)
+}
+
+// Variables
+
+func (c *Compiler) beginScope() {
+	c.depth++
+}
+
+func (c *Compiler) endScope() {
+	c.depth--
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.depth {
+		c.popLocal(c.locals[len(c.locals)-1])
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+// popLocal emits the instruction that discards a local once it goes out of
+// scope.
+func (c *Compiler) popLocal(local local) {
+	if local.captured {
+		c.emitOp(OpCloseUpvalue)
+	} else {
+		c.emitOp(OpPop)
+	}
+}
+
+// popLocals discards the locals below top that are deeper than depth,
+// without forgetting them, for jumps that leave their scopes. It returns the
+// number of locals that are left.
+func (c *Compiler) popLocals(top int, depth int) int {
+	for top > 0 && c.locals[top-1].depth > depth {
+		c.popLocal(c.locals[top-1])
+		top--
+	}
+	return top
+}
+
+func (c *Compiler) addLocal(name token.Token) {
+	if len(c.locals) == maxLocals {
+		c.error(name, "Too many local variables in function.")
+		return
+	}
+	c.locals = append(c.locals, local{name: name.Lexeme, depth: c.depth})
+}
+
+// declareVariable declares a variable in the current scope. It returns the
+// constant holding its name if the variable is a global.
+func (c *Compiler) declareVariable(name token.Token) int {
+	if c.depth == 0 {
+		return c.makeConstant(name.Lexeme)
+	}
+	c.addLocal(name)
+	return 0
+}
+
+// defineVariable defines the variable whose value is on top of the stack.
+// Locals already live there, so only globals need an instruction.
+func (c *Compiler) defineVariable(global int) {
+	if c.depth == 0 {
+		c.emitShort(OpDefineGlobal, global)
+	}
+}
+
+func (c *Compiler) resolveLocal(name string) int {
+	for i := len(c.locals) - 1; i >= 0; i-- {
+		if c.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Compiler) resolveUpvalue(name token.Token) int {
+	if c.enclosing == nil {
+		return -1
+	}
+
+	if local := c.enclosing.resolveLocal(name.Lexeme); local != -1 {
+		c.enclosing.locals[local].captured = true
+		return c.addUpvalue(name, byte(local), true)
+	}
+
+	if upvalue := c.enclosing.resolveUpvalue(name); upvalue != -1 {
+		return c.addUpvalue(name, byte(upvalue), false)
+	}
+
+	return -1
+}
+
+func (c *Compiler) addUpvalue(name token.Token, index byte, isLocal bool) int {
+	for i, upvalue := range c.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i
+		}
+	}
+
+	if len(c.upvalues) == maxLocals {
+		c.error(name, "Too many closure variables in function.")
+		return 0
+	}
+	c.upvalues = append(c.upvalues, upvalue{index, isLocal})
+	return len(c.upvalues) - 1
+}
+
+func (c *Compiler) getVariable(name token.Token) {
+	c.at(name)
+	if local := c.resolveLocal(name.Lexeme); local != -1 {
+		c.emitOp(OpGetLocal, byte(local))
+	} else if upvalue := c.resolveUpvalue(name); upvalue != -1 {
+		c.emitOp(OpGetUpvalue, byte(upvalue))
+	} else {
+		c.emitShort(OpGetGlobal, c.makeConstant(name.Lexeme))
+	}
+}
+
+func (c *Compiler) setVariable(name token.Token) {
+	c.at(name)
+	if local := c.resolveLocal(name.Lexeme); local != -1 {
+		c.emitOp(OpSetLocal, byte(local))
+	} else if upvalue := c.resolveUpvalue(name); upvalue != -1 {
+		c.emitOp(OpSetUpvalue, byte(upvalue))
+	} else {
+		c.emitShort(OpSetGlobal, c.makeConstant(name.Lexeme))
+	}
+}
+
+// closure compiles the body of a function and emits the closure that
+// creates it at runtime.
+func (c *Compiler) closure(kind functionKind, name string, params []token.Token, body []ast.Stmt) {
+	compiler := newCompiler(c, kind, name, c.diagnostics)
+	compiler.beginScope()
+	for _, param := range params {
+		compiler.addLocal(param)
+	}
+	compiler.function.Arity = len(params)
+	compiler.statements(body)
+	function := compiler.end()
+
+	c.emitShort(OpClosure, c.makeConstant(function))
+	for _, upvalue := range compiler.upvalues {
+		if upvalue.isLocal {
+			c.emit(1, upvalue.index)
+		} else {
+			c.emit(0, upvalue.index)
+		}
+	}
+}
+
+// Statements
+
+func (c *Compiler) VisitExpressionStmt(stmt *ast.ExpressionStmt) (any, error) {
+	c.expression(stmt.Expression)
+	c.emitOp(OpPop)
+	return nil, nil
+}
+
+func (c *Compiler) VisitFunctionStmt(stmt *ast.FunctionStmt) (any, error) {
+	c.at(stmt.Name)
+	global := c.declareVariable(stmt.Name)
+	c.closure(kindFunction, stmt.Name.Lexeme, stmt.Params, stmt.Body)
+	c.defineVariable(global)
+	return nil, nil
+}
+
+func (c *Compiler) VisitPrintStmt(stmt *ast.PrintStmt) (any, error) {
+	c.expression(stmt.Expression)
+	c.emitOp(OpPrint)
+	return nil, nil
+}
+
+func (c *Compiler) VisitReturnStmt(stmt *ast.ReturnStmt) (any, error) {
+	c.at(stmt.Keyword)
+	if stmt.Value == nil {
+		if c.try == nil {
+			c.emitReturn()
+			return nil, nil
+		}
+		if c.kind == kindInitializer {
+			c.emitOp(OpGetLocal, 0)
+		} else {
+			c.emitOp(OpNil)
+		}
+	} else {
+		c.expression(stmt.Value)
+	}
+
+	// The value is kept in each try statement's slot while its finally block
+	// runs.
+	top := len(c.locals)
+	for try := c.try; try != nil; try = try.enclosing {
+		if try.finally != nil {
+			slot := byte(try.locals - 1)
+			c.emitOp(OpSetLocal, slot)
+			c.emitOp(OpPop)
+			top = c.popLocals(top, try.depth)
+			c.emitOp(OpEndTry)
+			c.inlineFinally(try)
+			c.emitOp(OpGetLocal, slot)
+		} else {
+			c.emitOp(OpEndTry)
+		}
+	}
+	c.emitOp(OpReturn)
+	return nil, nil
+}
+
+func (c *Compiler) VisitVarStmt(stmt *ast.VarStmt) (any, error) {
+	if stmt.Initializer != nil {
+		c.expression(stmt.Initializer)
+	} else {
+		c.at(stmt.Name)
+		c.emitOp(OpNil)
+	}
+	c.at(stmt.Name)
+	c.defineVariable(c.declareVariable(stmt.Name))
+	return nil, nil
+}
+
+func (c *Compiler) VisitWhileStmt(stmt *ast.WhileStmt) (any, error) {
+	c.loop = &loop{enclosing: c.loop, depth: c.depth, try: c.try}
+
+	start := len(c.chunk().Code)
+	c.expression(stmt.Condition)
+	exit := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	_, _ = stmt.Body.Accept(c)
+
+	for _, jump := range c.loop.continues {
+		c.patchJump(jump)
+	}
+	if stmt.Increment != nil {
+		c.expression(stmt.Increment)
+		c.emitOp(OpPop)
+	}
+	c.emitLoop(start)
+
+	c.patchJump(exit)
+	c.emitOp(OpPop)
+	for _, jump := range c.loop.breaks {
+		c.patchJump(jump)
+	}
+
+	c.loop = c.loop.enclosing
+	return nil, nil
+}
+
+func (c *Compiler) VisitBlockStmt(stmt *ast.BlockStmt) (any, error) {
+	c.beginScope()
+	c.statements(stmt.Statements)
+	c.endScope()
+	return nil, nil
+}
+
+func (c *Compiler) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
+	c.at(stmt.Name)
+	global := c.declareVariable(stmt.Name)
+	c.emitShort(OpClass, c.makeConstant(stmt.Name.Lexeme))
+	c.defineVariable(global)
+
+	if stmt.Superclass != nil {
+		c.beginScope()
+		c.addLocal(token.Token{Lexeme: "super"})
+		c.getVariable(stmt.Superclass.Name)
+		c.getVariable(stmt.Name)
+		c.at(stmt.Superclass.Name)
+		c.emitOp(OpInherit)
+	}
+
+	c.getVariable(stmt.Name)
+	for _, method := range stmt.Methods {
+		c.at(method.Name)
+		kind := kindMethod
+		if method.Name.Lexeme == "init" {
+			kind = kindInitializer
+		}
+		c.closure(kind, method.Name.Lexeme, method.Params, method.Body)
+		c.emitShort(OpMethod, c.makeConstant(method.Name.Lexeme))
+	}
+	c.emitOp(OpPop)
+
+	if stmt.Superclass != nil {
+		c.endScope()
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitIfStmt(stmt *ast.IfStmt) (any, error) {
+	c.expression(stmt.Condition)
+	then := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	_, _ = stmt.ThenBranch.Accept(c)
+
+	end := c.emitJump(OpJump)
+	c.patchJump(then)
+	c.emitOp(OpPop)
+	if stmt.ElseBranch != nil {
+		_, _ = stmt.ElseBranch.Accept(c)
+	}
+	c.patchJump(end)
+	return nil, nil
+}
+
+func (c *Compiler) VisitBreakStmt(stmt *ast.BreakStmt) (any, error) {
+	c.at(stmt.Keyword)
+	c.popLocals(c.leaveTries(), c.loop.depth)
+	c.loop.breaks = append(c.loop.breaks, c.emitJump(OpJump))
+	return nil, nil
+}
+
+func (c *Compiler) VisitContinueStmt(stmt *ast.ContinueStmt) (any, error) {
+	c.at(stmt.Keyword)
+	c.popLocals(c.leaveTries(), c.loop.depth)
+	c.loop.continues = append(c.loop.continues, c.emitJump(OpJump))
+	return nil, nil
+}
+
+// leaveTries removes the handlers of the try blocks inside the innermost
+// loop and runs their finally blocks, for jumps out of the loop body. It
+// returns the number of locals that are left.
+func (c *Compiler) leaveTries() int {
+	top := len(c.locals)
+	for try := c.try; try != c.loop.try; try = try.enclosing {
+		top = c.popLocals(top, try.depth)
+		c.emitOp(OpEndTry)
+		if try.finally != nil {
+			c.inlineFinally(try)
+		}
+	}
+	return top
+}
+
+func (c *Compiler) VisitThrowStmt(stmt *ast.ThrowStmt) (any, error) {
+	c.expression(stmt.Value)
+	c.at(stmt.Keyword)
+	c.emitOp(OpThrow)
+	return nil, nil
+}
+
+// VisitTryStmt compiles a try statement. The finally block is compiled once
+// for each way out of the statement: falling through, an uncaught error, and
+// every break, continue and return that leaves it.
+func (c *Compiler) VisitTryStmt(stmt *ast.TryStmt) (any, error) {
+	c.at(stmt.Keyword)
+
+	// The slot for return values, see VisitReturnStmt.
+	c.beginScope()
+	c.emitOp(OpNil)
+	c.addLocal(token.Token{})
+	try := &tryBlock{enclosing: c.try, finally: stmt.Finally, depth: c.depth, locals: len(c.locals)}
+
+	handler := c.emitJump(OpTry)
+	c.try = try
+	_, _ = stmt.Body.Accept(c)
+	c.try = try.enclosing
+	c.emitOp(OpEndTry)
+	done := []int{c.emitJump(OpJump)}
+
+	// The handler is run with the error on top of the stack.
+	c.patchJump(handler)
+	if stmt.Catch != nil {
+		c.emitOp(OpCatch)
+		c.beginScope()
+		c.addLocal(stmt.Catch.Name)
+
+		if stmt.Finally != nil {
+			handler = c.emitJump(OpTry)
+			c.try = try
+		}
+		c.statements(stmt.Catch.Body)
+		if stmt.Finally != nil {
+			c.try = try.enclosing
+			c.emitOp(OpEndTry)
+		}
+
+		c.endScope()
+		done = append(done, c.emitJump(OpJump))
+	}
+
+	if stmt.Finally != nil {
+		if stmt.Catch != nil {
+			c.patchJump(handler)
+		}
+		// The error stays on the stack while the finally block runs.
+		c.beginScope()
+		c.addLocal(token.Token{})
+		_, _ = stmt.Finally.Accept(c)
+		c.emitOp(OpRethrow)
+		c.locals = c.locals[:len(c.locals)-1]
+		c.depth--
+	}
+
+	for _, jump := range done {
+		c.patchJump(jump)
+	}
+	if stmt.Finally != nil {
+		_, _ = stmt.Finally.Accept(c)
+	}
+	c.endScope()
+	return nil, nil
+}
+
+// inlineFinally compiles the finally block of a try statement that is left
+// early. By then only the locals from outside the statement are left.
+func (c *Compiler) inlineFinally(try *tryBlock) {
+	locals, depth, active := c.locals, c.depth, c.try
+	c.locals = append([]local(nil), locals[:try.locals]...)
+	c.depth, c.try = try.depth, try.enclosing
+
+	_, _ = try.finally.Accept(c)
+
+	for i := range locals[:try.locals] {
+		locals[i].captured = locals[i].captured || c.locals[i].captured
+	}
+	c.locals, c.depth, c.try = locals, depth, active
+}
+
+func (c *Compiler) VisitImportStmt(stmt *ast.ImportStmt) (any, error) {
+	c.at(stmt.Path)
+	c.emitShort(OpImport, c.makeConstant(stmt.Path))
+
+	if stmt.Alias != nil {
+		c.at(*stmt.Alias)
+		c.defineVariable(c.declareVariable(*stmt.Alias))
+		return nil, nil
+	}
+
+	// Imports are only allowed at the top level, so the names are globals.
+	for _, name := range stmt.Names {
+		c.at(name)
+		c.emitOp(OpDup)
+		c.emitShort(OpGetProperty, c.makeConstant(name.Lexeme))
+		c.emitShort(OpDefineGlobal, c.makeConstant(name.Lexeme))
+	}
+	c.emitOp(OpPop)
+	return nil, nil
+}
+
+func (c *Compiler) VisitExportStmt(stmt *ast.ExportStmt) (any, error) {
+	return stmt.Declaration.Accept(c)
+}
+
+// Expressions
+
+func (c *Compiler) VisitBinaryExpr(expr *ast.BinaryExpr) (any, error) {
+	c.expression(expr.Left)
+	c.expression(expr.Right)
+
+	c.at(expr.Operator)
+	switch expr.Operator.Type {
+	case token.Greater:
+		c.emitOp(OpGreater)
+	case token.GreaterEqual:
+		c.emitOp(OpGreaterEqual)
+	case token.Less:
+		c.emitOp(OpLess)
+	case token.LessEqual:
+		c.emitOp(OpLessEqual)
+	case token.BangEqual:
+		c.emitOp(OpNotEqual)
+	case token.EqualEqual:
+		c.emitOp(OpEqual)
+	case token.Minus:
+		c.emitOp(OpSubtract)
+	case token.Plus:
+		c.emitOp(OpAdd)
+	case token.Interpolation:
+		c.emitOp(OpConcat)
+	case token.Slash:
+		c.emitOp(OpDivide)
+	case token.Star:
+		c.emitOp(OpMultiply)
+	case token.Percent:
+		c.emitOp(OpModulo)
+	case token.TildeSlash:
+		c.emitOp(OpFloorDivide)
+	case token.StarStar:
+		c.emitOp(OpPower)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitCallExpr(expr *ast.CallExpr) (any, error) {
+	c.expression(expr.Callee)
+	for _, argument := range expr.Arguments {
+		c.expression(argument)
+	}
+	c.at(expr.Paren)
+	c.emitOp(OpCall, byte(len(expr.Arguments)))
+	return nil, nil
+}
+
+func (c *Compiler) VisitGetExpr(expr *ast.GetExpr) (any, error) {
+	c.expression(expr.Object)
+	c.at(expr.Name)
+	c.emitShort(OpGetProperty, c.makeConstant(expr.Name.Lexeme))
+	return nil, nil
+}
+
+func (c *Compiler) VisitSetExpr(expr *ast.SetExpr) (any, error) {
+	c.expression(expr.Object)
+	c.expression(expr.Value)
+	c.at(expr.Name)
+	c.emitShort(OpSetProperty, c.makeConstant(expr.Name.Lexeme))
+	return nil, nil
+}
+
+func (c *Compiler) VisitSuperExpr(expr *ast.SuperExpr) (any, error) {
+	c.getVariable(token.Token{Lexeme: "this", Line: expr.Keyword.Line, Column: expr.Keyword.Column})
+	c.getVariable(expr.Keyword)
+	c.at(expr.Method)
+	c.emitShort(OpGetSuper, c.makeConstant(expr.Method.Lexeme))
+	return nil, nil
+}
+
+func (c *Compiler) VisitThisExpr(expr *ast.ThisExpr) (any, error) {
+	c.getVariable(expr.Keyword)
+	return nil, nil
+}
+
+func (c *Compiler) VisitGroupingExpr(expr *ast.GroupingExpr) (any, error) {
+	c.expression(expr.Expression)
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexGetExpr(expr *ast.IndexGetExpr) (any, error) {
+	c.expression(expr.Object)
+	c.expression(expr.Index)
+	c.at(expr.Bracket)
+	c.emitOp(OpGetIndex)
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexSetExpr(expr *ast.IndexSetExpr) (any, error) {
+	c.expression(expr.Object)
+	c.expression(expr.Index)
+	c.expression(expr.Value)
+	c.at(expr.Bracket)
+	c.emitOp(OpSetIndex)
+	return nil, nil
+}
+
+func (c *Compiler) VisitLambdaExpr(expr *ast.LambdaExpr) (any, error) {
+	c.closure(kindLambda, "", expr.Params, expr.Body)
+	return nil, nil
+}
+
+func (c *Compiler) VisitListExpr(expr *ast.ListExpr) (any, error) {
+	for _, element := range expr.Elements {
+		c.expression(element)
+	}
+	c.at(expr.Bracket)
+	if len(expr.Elements) > math.MaxUint16 {
+		c.error(expr.Bracket, "Too many elements in list literal.")
+	}
+	c.emitShort(OpList, len(expr.Elements))
+	return nil, nil
+}
+
+func (c *Compiler) VisitLiteralExpr(expr *ast.LiteralExpr) (any, error) {
+	switch expr.Value {
+	case nil:
+		c.emitOp(OpNil)
+	case true:
+		c.emitOp(OpTrue)
+	case false:
+		c.emitOp(OpFalse)
+	default:
+		c.emitConstant(expr.Value)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitMapExpr(expr *ast.MapExpr) (any, error) {
+	for index, key := range expr.Keys {
+		c.expression(key)
+		c.expression(expr.Values[index])
+	}
+	c.at(expr.Brace)
+	if len(expr.Keys) > math.MaxUint16 {
+		c.error(expr.Brace, "Too many entries in map literal.")
+	}
+	c.emitShort(OpMap, len(expr.Keys))
+	return nil, nil
+}
+
+func (c *Compiler) VisitUnaryExpr(expr *ast.UnaryExpr) (any, error) {
+	c.expression(expr.Right)
+	c.at(expr.Operator)
+	switch expr.Operator.Type {
+	case token.Bang:
+		c.emitOp(OpNot)
+	case token.Minus:
+		c.emitOp(OpNegate)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitVariableExpr(expr *ast.VariableExpr) (any, error) {
+	c.getVariable(expr.Name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitAssignExpr(expr *ast.AssignExpr) (any, error) {
+	c.expression(expr.Value)
+	c.setVariable(expr.Name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitLogicalExpr(expr *ast.LogicalExpr) (any, error) {
+	c.expression(expr.Left)
+	c.at(expr.Operator)
+
+	var jump int
+	if expr.Operator.Type == token.Or {
+		skip := c.emitJump(OpJumpIfFalse)
+		jump = c.emitJump(OpJump)
+		c.patchJump(skip)
+	} else {
+		jump = c.emitJump(OpJumpIfFalse)
+	}
+
+	c.emitOp(OpPop)
+	c.expression(expr.Right)
+	c.patchJump(jump)
+	return nil, nil
+}
@@ -0,0 +1,115 @@
+package bytecode
+
+import (
+	"fmt"
+	"interp/interpreter"
+)
+
+// Function is a compiled function. At runtime it is always wrapped in a
+// Closure.
+type Function struct {
+	Name         string
+	Arity        int
+	UpvalueCount int
+	Chunk        Chunk
+
+	// lambda is set for functions compiled from lambda expressions.
+	lambda bool
+}
+
+type Closure struct {
+	function *Function
+	upvalues []*Upvalue
+	// globals are the globals of the module the closure was created in.
+	globals Globals
+	// file is the file of that module, for stack traces.
+	file string
+}
+
+// Globals are the global variables of a module.
+type Globals map[string]any
+
+func newGlobals() Globals {
+	globals := Globals{}
+	for name, builtin := range interpreter.Builtins() {
+		globals[name] = builtin
+	}
+	return globals
+}
+
+func (g Globals) Lookup(name string) (any, bool) {
+	value, ok := g[name]
+	return value, ok
+}
+
+func (c *Closure) String() string {
+	if c.function.lambda {
+		return "<lambda>"
+	}
+	return fmt.Sprintf("<fn %s>", c.function.Name)
+}
+
+func (c *Closure) TypeName() string {
+	return "function"
+}
+
+// Upvalue is a variable captured by a closure. While the variable is still on
+// the stack it is open and refers to its slot; once it goes out of scope, the
+// value is moved into closed.
+type Upvalue struct {
+	slot   int
+	open   bool
+	closed any
+	next   *Upvalue
+}
+
+type Class struct {
+	Name       string
+	superclass *Class
+	methods    map[string]*Closure
+}
+
+func (c *Class) findMethod(name string) *Closure {
+	if method, ok := c.methods[name]; ok {
+		return method
+	}
+	if c.superclass != nil {
+		return c.superclass.findMethod(name)
+	}
+	return nil
+}
+
+func (c *Class) String() string {
+	return c.Name
+}
+
+func (c *Class) TypeName() string {
+	return "class"
+}
+
+type Instance struct {
+	class  *Class
+	fields map[string]any
+}
+
+func (i *Instance) String() string {
+	return i.class.Name + " instance"
+}
+
+func (i *Instance) TypeName() string {
+	return "instance"
+}
+
+// BoundMethod is a method bound to the instance it was accessed on.
+type BoundMethod struct {
+	receiver *Instance
+	method   *Closure
+}
+
+func (b *BoundMethod) String() string {
+	return b.method.String()
+}
+
+func (b *BoundMethod) TypeName() string {
+	return "function"
+}
@@ -0,0 +1,68 @@
+package bytecode
+
+type OpCode byte
+
+// Operands follow the opcode in the code. Constant indexes, global names and
+// jump offsets take two bytes, big endian; local slots, upvalue indexes and
+// argument counts take one.
+const (
+	OpConstant OpCode = iota
+	OpNil
+	OpTrue
+	OpFalse
+	OpPop
+	OpDup
+
+	OpGetLocal
+	OpSetLocal
+	OpGetGlobal
+	OpDefineGlobal
+	OpSetGlobal
+	OpGetUpvalue
+	OpSetUpvalue
+	OpGetProperty
+	OpSetProperty
+	OpGetSuper
+	OpGetIndex
+	OpSetIndex
+
+	OpEqual
+	OpNotEqual
+	OpGreater
+	OpGreaterEqual
+	OpLess
+	OpLessEqual
+	OpAdd
+	OpSubtract
+	OpMultiply
+	OpDivide
+	OpModulo
+	OpFloorDivide
+	OpPower
+	// OpConcat joins its operands as print shows them, for interpolation.
+	OpConcat
+	OpNot
+	OpNegate
+
+	OpPrint
+	OpJump
+	OpJumpIfFalse
+	OpLoop
+	OpCall
+	OpClosure
+	OpCloseUpvalue
+	OpReturn
+
+	OpThrow
+	OpTry
+	OpEndTry
+	OpCatch
+	OpRethrow
+	OpImport
+
+	OpClass
+	OpInherit
+	OpMethod
+	OpList
+	OpMap
+)
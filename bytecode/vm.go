@@ -0,0 +1,700 @@
+package bytecode
+
+import (
+	"fmt"
+	"interp/ast"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/token"
+	"io"
+	"os"
+)
+
+type frame struct {
+	closure *Closure
+	ip      int
+	// base is the stack index of the frame's slot 0.
+	base int
+}
+
+// handler is an installed try block. When an error occurs, the frames and
+// stack are unwound to what they were when it was installed and execution
+// continues at ip.
+type handler struct {
+	frame int
+	top   int
+	ip    int
+}
+
+// VM runs compiled functions. Natives are shared with the tree-walking
+// interpreter, which the VM keeps around to call them with.
+type VM struct {
+	frames       []frame
+	stack        []any
+	top          int
+	globals      Globals
+	openUpvalues *Upvalue
+	handlers     []handler
+	runtime      *interpreter.Interpreter
+	stdout       io.Writer
+	file         string
+	modules      *interpreter.Modules
+}
+
+func NewVM() *VM {
+	runtime := interpreter.NewInterpreter()
+
+	vm := &VM{
+		stack:   make([]any, 256),
+		globals: newGlobals(),
+		runtime: &runtime,
+		modules: interpreter.NewModules(),
+	}
+	vm.SetOutput(nil)
+	return vm
+}
+
+// SetInput sets the reader used by natives that read input.
+func (vm *VM) SetInput(reader io.Reader) {
+	vm.runtime.SetInput(reader)
+}
+
+// SetOutput sets the writer that print statements write to. A nil writer
+// selects standard output.
+func (vm *VM) SetOutput(writer io.Writer) {
+	if writer == nil {
+		writer = os.Stdout
+	}
+	vm.stdout = writer
+	vm.runtime.SetOutput(writer)
+}
+
+// SetFile sets the name of the script being run, which stack traces show and
+// imports are relative to.
+func (vm *VM) SetFile(file string) {
+	vm.file = file
+	vm.modules.SetEntry(file)
+}
+
+// SetSearchPath sets the directories that imported modules are looked for in
+// when they aren't found relative to the importing script.
+func (vm *VM) SetSearchPath(directories []string) {
+	vm.modules.SetSearchPath(directories)
+}
+
+func (vm *VM) Define(name string, value any) {
+	vm.globals[name] = value
+}
+
+// Global returns the value of a global variable.
+func (vm *VM) Global(name string) (any, bool) {
+	value, ok := vm.globals[name]
+	return value, ok
+}
+
+// Run runs a function returned by Compile. Runtime errors are returned as
+// errors.RuntimeError.
+func (vm *VM) Run(function *Function) error {
+	err := vm.execute(&Closure{function: function, globals: vm.globals, file: vm.file})
+	if throw, ok := err.(interpreter.Throw); ok {
+		return vm.runtime.Uncaught(throw.Value, throw.Keyword, throw.Stack())
+	}
+	return err
+}
+
+// execute runs the closure of a script. Errors that aren't caught are
+// returned as they are.
+func (vm *VM) execute(closure *Closure) error {
+	vm.push(closure)
+	err := vm.call(closure, 0, token.Token{})
+	if err == nil {
+		err = vm.withStack(vm.run())
+		for err != nil && vm.handle(err) {
+			err = vm.withStack(vm.run())
+		}
+	}
+	if err != nil {
+		vm.reset()
+	}
+	return err
+}
+
+// runModule runs a module on a VM of its own, which shares everything but
+// the stack and the globals with this one. The stack traces of its errors
+// continue with the import.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func (vm *VM) runModule(file string, path token.Token, statements []ast.Stmt, diagnostics *errors.Diagnostics) (interpreter.Globals, error) {
+	function := Compile(statements, diagnostics)
+	if diagnostics.HasErrors() {
+		return nil, nil
+	}
+
+	module := &VM{
+		stack:   make([]any, 256),
+		globals: newGlobals(),
+		runtime: vm.runtime,
+		stdout:  vm.stdout,
+		file:    file,
+		modules: vm.modules,
+	}
+	err := module.execute(&Closure{function: function, globals: module.globals, file: file})
+
+	// The module's stack ends with its top level, which was called by the
+	// import.
+	importer := func(stack []errors.StackFrame) []errors.StackFrame {
+		stack = append([]errors.StackFrame(nil), stack...)
+		stack[len(stack)-1].Function = interpreter.ModuleFrame(path)
+		return append(stack, vm.stackTrace(path)...)
+	}
+	switch e := err.(type) {
+	case errors.RuntimeError:
+		err = e.WithStack(importer(e.Stack()))
+	case interpreter.Throw:
+		err = interpreter.NewThrow(e.Value, e.Keyword, importer(e.Stack()))
+	}
+	return module.globals, err
+}
+
+// handle unwinds to the innermost handler and leaves the error on the stack
+// for it. It reports false if the error can't be caught.
+func (vm *VM) handle(err error) bool {
+	if _, ok := interpreter.Caught(err); !ok || len(vm.handlers) == 0 {
+		return false
+	}
+
+	handler := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.closeUpvalues(handler.top)
+	for vm.top > handler.top {
+		vm.pop()
+	}
+	vm.frames = vm.frames[:handler.frame+1]
+	vm.frames[handler.frame].ip = handler.ip
+	vm.push(err)
+	return true
+}
+
+// withStack attaches the call stack to a runtime error that doesn't have one
+// yet. The frames must not have been unwound.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func (vm *VM) withStack(err error) error {
+	if err, ok := err.(errors.RuntimeError); ok && err.Stack() == nil {
+		return err.WithStack(vm.stackTrace(err.Token()))
+	}
+	return err
+}
+
+// stackTrace returns the calls in progress, innermost first, given the token
+// the innermost one has got to.
+func (vm *VM) stackTrace(at token.Token) []errors.StackFrame {
+	stack := make([]errors.StackFrame, 0, len(vm.frames))
+	for index := len(vm.frames) - 1; index >= 0; index-- {
+		frame := vm.frames[index]
+		name := frame.closure.function.Name
+		if frame.closure.function.lambda {
+			name = "<lambda>"
+		}
+		stack = append(stack, errors.StackFrame{Function: name, File: frame.closure.file, Token: at})
+		// The caller is at its call instruction, which has a one byte operand.
+		if index > 0 {
+			caller := vm.frames[index-1]
+			at = caller.closure.function.Chunk.token(caller.ip - 2)
+		}
+	}
+	return stack
+}
+
+func (vm *VM) reset() {
+	for i := range vm.stack[:vm.top] {
+		vm.stack[i] = nil
+	}
+	vm.top = 0
+	vm.frames = vm.frames[:0]
+	vm.handlers = vm.handlers[:0]
+	vm.openUpvalues = nil
+}
+
+// Stack
+
+func (vm *VM) push(value any) {
+	if vm.top == len(vm.stack) {
+		stack := make([]any, 2*len(vm.stack))
+		copy(stack, vm.stack)
+		vm.stack = stack
+	}
+	vm.stack[vm.top] = value
+	vm.top++
+}
+
+func (vm *VM) pop() any {
+	vm.top--
+	value := vm.stack[vm.top]
+	vm.stack[vm.top] = nil
+	return value
+}
+
+func (vm *VM) peek(distance int) any {
+	return vm.stack[vm.top-1-distance]
+}
+
+// Calls
+
+func (vm *VM) callValue(callee any, count int, paren token.Token) error {
+	switch callee := callee.(type) {
+	case *Closure:
+		return vm.call(callee, count, paren)
+	case *BoundMethod:
+		vm.stack[vm.top-count-1] = callee.receiver
+		return vm.call(callee.method, count, paren)
+	case *Class:
+		vm.stack[vm.top-count-1] = &Instance{class: callee, fields: map[string]any{}}
+		if initializer := callee.findMethod("init"); initializer != nil {
+			return vm.call(initializer, count, paren)
+		}
+		if count != 0 {
+			return arityError(paren, 0, count)
+		}
+		return nil
+	case interpreter.Callable:
+		if count != callee.Arity() {
+			return arityError(paren, callee.Arity(), count)
+		}
+		arguments := make([]any, count)
+		copy(arguments, vm.stack[vm.top-count:vm.top])
+		result, err := callee.Call(vm.runtime, arguments)
+		if err, ok := err.(interpreter.NativeError); ok {
+			return errors.NewRuntimeError(paren, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		for i := 0; i <= count; i++ {
+			vm.pop()
+		}
+		vm.push(result)
+		return nil
+	}
+	return errors.NewRuntimeError(paren, "Can only call functions and classes.")
+}
+
+func (vm *VM) call(closure *Closure, count int, paren token.Token) error {
+	if count != closure.function.Arity {
+		return arityError(paren, closure.function.Arity, count)
+	}
+	if len(vm.frames) == interpreter.MaxFrames {
+		return errors.NewRuntimeError(paren, "Stack overflow.")
+	}
+	vm.frames = append(vm.frames, frame{closure: closure, base: vm.top - count - 1})
+	return nil
+}
+
+func arityError(paren token.Token, arity int, count int) error {
+	return errors.NewRuntimeError(paren, fmt.Sprintf("Expected %d arguments but got %d.", arity, count))
+}
+
+// Upvalues
+
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	var previous *Upvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		previous = upvalue
+		upvalue = upvalue.next
+	}
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	created := &Upvalue{slot: slot, open: true, next: upvalue}
+	if previous == nil {
+		vm.openUpvalues = created
+	} else {
+		previous.next = created
+	}
+	return created
+}
+
+// closeUpvalues closes the open upvalues of the slots from last upwards.
+func (vm *VM) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.closed = vm.stack[upvalue.slot]
+		upvalue.open = false
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+func (vm *VM) getUpvalue(upvalue *Upvalue) any {
+	if upvalue.open {
+		return vm.stack[upvalue.slot]
+	}
+	return upvalue.closed
+}
+
+func (vm *VM) setUpvalue(upvalue *Upvalue, value any) {
+	if upvalue.open {
+		vm.stack[upvalue.slot] = value
+	} else {
+		upvalue.closed = value
+	}
+}
+
+// Execution
+
+//goland:noinspection GoTypeAssertionOnErrors
+func (vm *VM) run() error {
+	frame := &vm.frames[len(vm.frames)-1]
+	chunk := &frame.closure.function.Chunk
+
+	readByte := func() byte {
+		b := chunk.Code[frame.ip]
+		frame.ip++
+		return b
+	}
+	readShort := func() int {
+		short := int(chunk.Code[frame.ip])<<8 | int(chunk.Code[frame.ip+1])
+		frame.ip += 2
+		return short
+	}
+	readString := func() string {
+		return chunk.Constants[readShort()].(string)
+	}
+
+	// at returns a token with the given lexeme at the position of the
+	// instruction being run, to report errors at.
+	var start int
+	at := func(lexeme string) token.Token {
+		t := chunk.token(start)
+		t.Lexeme = lexeme
+		return t
+	}
+
+	for {
+		start = frame.ip
+		switch OpCode(readByte()) {
+		case OpConstant:
+			vm.push(chunk.Constants[readShort()])
+		case OpNil:
+			vm.push(nil)
+		case OpTrue:
+			vm.push(true)
+		case OpFalse:
+			vm.push(false)
+		case OpPop:
+			vm.pop()
+		case OpDup:
+			vm.push(vm.peek(0))
+
+		case OpGetLocal:
+			vm.push(vm.stack[frame.base+int(readByte())])
+		case OpSetLocal:
+			vm.stack[frame.base+int(readByte())] = vm.peek(0)
+		case OpGetGlobal:
+			name := readString()
+			value, ok := frame.closure.globals[name]
+			if !ok {
+				return errors.NewRuntimeError(at(name), fmt.Sprintf("Undefined variable '%s'.", name))
+			}
+			vm.push(value)
+		case OpDefineGlobal:
+			frame.closure.globals[readString()] = vm.pop()
+		case OpSetGlobal:
+			name := readString()
+			if _, ok := frame.closure.globals[name]; !ok {
+				return errors.NewRuntimeError(at(name), fmt.Sprintf("Undefined variable '%s'.", name))
+			}
+			frame.closure.globals[name] = vm.peek(0)
+		case OpGetUpvalue:
+			vm.push(vm.getUpvalue(frame.closure.upvalues[readByte()]))
+		case OpSetUpvalue:
+			vm.setUpvalue(frame.closure.upvalues[readByte()], vm.peek(0))
+
+		case OpGetProperty:
+			name := readString()
+			value, err := vm.getProperty(vm.peek(0), at(name))
+			if err != nil {
+				return err
+			}
+			vm.pop()
+			vm.push(value)
+		case OpSetProperty:
+			name := readString()
+			instance, ok := vm.peek(1).(*Instance)
+			if !ok {
+				return errors.NewRuntimeError(at(name), "Only instances have fields.")
+			}
+			value := vm.pop()
+			instance.fields[name] = value
+			vm.pop()
+			vm.push(value)
+		case OpGetSuper:
+			name := readString()
+			superclass := vm.pop().(*Class)
+			method := superclass.findMethod(name)
+			if method == nil {
+				return errors.NewRuntimeError(at(name), fmt.Sprintf("Undefined property '%s'.", name))
+			}
+			receiver := vm.pop().(*Instance)
+			vm.push(&BoundMethod{receiver: receiver, method: method})
+		case OpGetIndex:
+			index := vm.pop()
+			var value any
+			var err error
+			switch object := vm.pop().(type) {
+			case *interpreter.List:
+				value, err = object.GetIndex(at("["), index)
+			case *interpreter.Map:
+				value, err = object.GetIndex(at("["), index)
+			default:
+				err = errors.NewRuntimeError(at("["), "Only lists and maps can be indexed.")
+			}
+			if err != nil {
+				return err
+			}
+			vm.push(value)
+		case OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			var err error
+			switch object := vm.pop().(type) {
+			case *interpreter.List:
+				err = object.SetIndex(at("["), index, value)
+			case *interpreter.Map:
+				err = object.SetIndex(at("["), index, value)
+			default:
+				err = errors.NewRuntimeError(at("["), "Only lists and maps can be indexed.")
+			}
+			if err != nil {
+				return err
+			}
+			vm.push(value)
+
+		case OpEqual:
+			right := vm.pop()
+			vm.push(interpreter.Equal(vm.pop(), right))
+		case OpNotEqual:
+			right := vm.pop()
+			vm.push(!interpreter.Equal(vm.pop(), right))
+		case OpGreater, OpGreaterEqual, OpLess, OpLessEqual:
+			result, err := interpreter.Comparison(operators[OpCode(chunk.Code[start])], vm.peek(1), vm.peek(0))
+			if err != nil {
+				return errors.NewRuntimeError(at(""), err.Error())
+			}
+			vm.pop()
+			vm.pop()
+			vm.push(result)
+		case OpAdd, OpSubtract, OpMultiply, OpDivide, OpModulo, OpFloorDivide, OpPower:
+			left, right := vm.peek(1), vm.peek(0)
+			if OpCode(chunk.Code[start]) == OpAdd {
+				if left, ok := left.(string); ok {
+					if right, ok := right.(string); ok {
+						vm.pop()
+						vm.pop()
+						vm.push(left + right)
+						continue
+					}
+				}
+				if !interpreter.IsNumber(left) || !interpreter.IsNumber(right) {
+					return errors.NewRuntimeError(at(""), "Operands must be two numbers or two strings.")
+				}
+			}
+			result, err := interpreter.Arithmetic(operators[OpCode(chunk.Code[start])], left, right)
+			if err != nil {
+				return errors.NewRuntimeError(at(""), err.Error())
+			}
+			vm.pop()
+			vm.pop()
+			vm.push(result)
+		case OpConcat:
+			right := vm.pop()
+			left := vm.pop()
+			vm.push(vm.runtime.Stringify(left) + vm.runtime.Stringify(right))
+		case OpNot:
+			vm.push(!isTruthy(vm.pop()))
+		case OpNegate:
+			value, ok := interpreter.Negate(vm.peek(0))
+			if !ok {
+				return errors.NewRuntimeError(at(""), "Operand must be a number.")
+			}
+			vm.pop()
+			vm.push(value)
+
+		case OpPrint:
+			_, err := fmt.Fprintln(vm.stdout, vm.runtime.Stringify(vm.pop()))
+			if err != nil {
+				return err
+			}
+		case OpJump:
+			offset := readShort()
+			frame.ip += offset
+		case OpJumpIfFalse:
+			offset := readShort()
+			if !isTruthy(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case OpLoop:
+			offset := readShort()
+			frame.ip -= offset
+		case OpCall:
+			count := int(readByte())
+			err := vm.callValue(vm.peek(count), count, at("("))
+			if err != nil {
+				return err
+			}
+			frame = &vm.frames[len(vm.frames)-1]
+			chunk = &frame.closure.function.Chunk
+		case OpClosure:
+			function := chunk.Constants[readShort()].(*Function)
+			closure := &Closure{
+				function: function,
+				upvalues: make([]*Upvalue, function.UpvalueCount),
+				globals:  frame.closure.globals,
+				file:     frame.closure.file,
+			}
+			for i := range closure.upvalues {
+				isLocal := readByte()
+				index := int(readByte())
+				if isLocal == 1 {
+					closure.upvalues[i] = vm.captureUpvalue(frame.base + index)
+				} else {
+					closure.upvalues[i] = frame.closure.upvalues[index]
+				}
+			}
+			vm.push(closure)
+		case OpCloseUpvalue:
+			vm.closeUpvalues(vm.top - 1)
+			vm.pop()
+		case OpReturn:
+			result := vm.pop()
+			vm.closeUpvalues(frame.base)
+			for vm.top > frame.base {
+				vm.pop()
+			}
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == 0 {
+				return nil
+			}
+			vm.push(result)
+			frame = &vm.frames[len(vm.frames)-1]
+			chunk = &frame.closure.function.Chunk
+
+		case OpThrow:
+			keyword := at("throw")
+			return interpreter.NewThrow(vm.pop(), keyword, vm.stackTrace(keyword))
+		case OpTry:
+			offset := readShort()
+			vm.handlers = append(vm.handlers, handler{len(vm.frames) - 1, vm.top, frame.ip + offset})
+		case OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case OpCatch:
+			value, _ := interpreter.Caught(vm.pop().(error))
+			vm.push(value)
+		case OpRethrow:
+			return vm.pop().(error)
+
+		case OpImport:
+			path := chunk.Constants[readShort()].(token.Token)
+			module, err := vm.modules.Import(vm.file, path, discardLocals{}, vm.runModule)
+			if err != nil {
+				return err
+			}
+			vm.push(module)
+
+		case OpClass:
+			vm.push(&Class{Name: readString(), methods: map[string]*Closure{}})
+		case OpInherit:
+			superclass, ok := vm.peek(1).(*Class)
+			if !ok {
+				return errors.NewRuntimeError(at(""), "Superclass must be a class.")
+			}
+			vm.pop().(*Class).superclass = superclass
+		case OpMethod:
+			name := readString()
+			method := vm.pop().(*Closure)
+			vm.peek(0).(*Class).methods[name] = method
+		case OpList:
+			count := readShort()
+			elements := make([]any, count)
+			copy(elements, vm.stack[vm.top-count:vm.top])
+			for i := 0; i < count; i++ {
+				vm.pop()
+			}
+			vm.push(interpreter.NewList(elements))
+		case OpMap:
+			count := readShort()
+			result := interpreter.NewMap()
+			first := vm.top - 2*count
+			for i := first; i < vm.top; i += 2 {
+				err := result.SetIndex(at("{"), vm.stack[i], vm.stack[i+1])
+				if err != nil {
+					return err
+				}
+			}
+			for i := 0; i < 2*count; i++ {
+				vm.pop()
+			}
+			vm.push(result)
+		}
+	}
+}
+
+func (vm *VM) getProperty(object any, name token.Token) (any, error) {
+	switch object := object.(type) {
+	case *Instance:
+		if field, ok := object.fields[name.Lexeme]; ok {
+			return field, nil
+		}
+		if method := object.class.findMethod(name.Lexeme); method != nil {
+			return &BoundMethod{receiver: object, method: method}, nil
+		}
+		return nil, errors.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+	case *interpreter.List:
+		return object.Property(name)
+	case *interpreter.Map:
+		return object.Property(name)
+	case *interpreter.Error:
+		return object.Property(name)
+	case *interpreter.Module:
+		return object.Property(name)
+	}
+	return nil, errors.NewRuntimeError(name, "Only instances have properties.")
+}
+
+func isTruthy(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case int64:
+		return value != 0
+	case float64:
+		return value != 0
+	}
+	return true
+}
+
+// operators are the operators that the arithmetic and comparison
+// instructions apply.
+var operators = map[OpCode]token.TokenType{
+	OpGreater:      token.Greater,
+	OpGreaterEqual: token.GreaterEqual,
+	OpLess:         token.Less,
+	OpLessEqual:    token.LessEqual,
+	OpAdd:          token.Plus,
+	OpSubtract:     token.Minus,
+	OpMultiply:     token.Star,
+	OpDivide:       token.Slash,
+	OpModulo:       token.Percent,
+	OpFloorDivide:  token.TildeSlash,
+	OpPower:        token.StarStar,
+}
+
+// discardLocals is given to the resolver for modules; the compiler resolves
+// variables itself.
+type discardLocals struct{}
+
+func (discardLocals) Resolve(ast.Expr, int, int) {}
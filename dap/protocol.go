@@ -0,0 +1,95 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// request is a request sent by the client.
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// readMessage reads the content of the next message, which follows a
+// Content-Length header and an empty line.
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// writer writes messages, numbering them. It can be used from several
+// goroutines.
+type writer struct {
+	mutex sync.Mutex
+	w     io.Writer
+	seq   int
+}
+
+func (w *writer) respond(request request, body any) {
+	w.write(&response{Type: "response", RequestSeq: request.Seq, Success: true, Command: request.Command, Body: body})
+}
+
+func (w *writer) fail(request request, message string) {
+	w.write(&response{Type: "response", RequestSeq: request.Seq, Command: request.Command, Message: message})
+}
+
+func (w *writer) event(name string, body any) {
+	w.write(&event{Type: "event", Event: name, Body: body})
+}
+
+func (w *writer) write(message any) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.seq++
+	switch message := message.(type) {
+	case *response:
+		message.Seq = w.seq
+	case *event:
+		message.Seq = w.seq
+	}
+
+	content, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w.w, "Content-Length: %d\r\n\r\n%s", len(content), content)
+}
@@ -0,0 +1,488 @@
+// Package dap implements a Debug Adapter Protocol server, which lets editors
+// debug scripts run by the tree-walking interpreter.
+//
+// The server debugs a single script, given by the launch request, on a single
+// thread. The script runs in a goroutine of its own; requests that inspect it
+// are handled on that goroutine while it is paused.
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"interp/ast"
+	"interp/debugger"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/parser"
+	"interp/resolver"
+	"interp/scanner"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// threadID is the ID of the only thread.
+const threadID = 1
+
+// Exit codes reported when the script ends.
+const (
+	exitOK      = 0
+	exitRuntime = 70
+)
+
+// command is run by the paused script. It reports whether the script should
+// go on.
+type command func(d *debugger.Debugger) bool
+
+type Server struct {
+	in  *bufio.Reader
+	out *writer
+
+	interpreter *interpreter.Interpreter
+	debugger    *debugger.Debugger
+	statements  []ast.Stmt
+	launched    bool
+	stopOnEntry bool
+	running     bool
+
+	// commands are run by the script while it is paused.
+	commands chan command
+	// mutex guards paused, and quit being closed.
+	mutex  sync.Mutex
+	paused bool
+	// quit is closed when the client disconnects, which releases the script
+	// if it is paused and stops it from pausing again.
+	quit chan struct{}
+	// handles are the variable references handed out while the script is
+	// paused. Only the script uses them.
+	handles []any
+	// done is closed when the script has ended.
+	done chan struct{}
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	inter := interpreter.NewInterpreter()
+	s := &Server{
+		in:          bufio.NewReader(in),
+		out:         &writer{w: out},
+		interpreter: &inter,
+		commands:    make(chan command),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	s.debugger = debugger.New(s.interpreter, s.stopped)
+	inter.SetOutput(outputWriter{s.out, "stdout"})
+	return s
+}
+
+// Serve handles requests until the client disconnects or the input ends.
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			s.disconnect()
+			return nil
+		}
+		if err != nil {
+			s.disconnect()
+			return err
+		}
+
+		var request request
+		if err := json.Unmarshal(content, &request); err != nil || request.Type != "request" {
+			continue
+		}
+		if !s.handle(request) {
+			return nil
+		}
+	}
+}
+
+// handle handles a request. It reports false once the client disconnected.
+func (s *Server) handle(request request) bool {
+	switch request.Command {
+	case "initialize":
+		s.out.respond(request, map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+		})
+		s.out.event("initialized", nil)
+	case "launch":
+		s.launch(request)
+	case "setBreakpoints":
+		s.setBreakpoints(request)
+	case "setExceptionBreakpoints":
+		s.out.respond(request, map[string]any{"breakpoints": []any{}})
+	case "configurationDone":
+		s.configurationDone(request)
+	case "threads":
+		s.out.respond(request, map[string]any{
+			"threads": []any{map[string]any{"id": threadID, "name": "main"}},
+		})
+	case "continue":
+		s.resume(request, (*debugger.Debugger).Continue, map[string]any{"allThreadsContinued": true})
+	case "next":
+		s.resume(request, (*debugger.Debugger).StepOver, nil)
+	case "stepIn":
+		s.resume(request, (*debugger.Debugger).StepInto, nil)
+	case "stepOut":
+		s.resume(request, (*debugger.Debugger).StepOut, nil)
+	case "stackTrace":
+		s.whilePaused(request, s.stackTrace)
+	case "scopes":
+		s.whilePaused(request, s.scopes)
+	case "variables":
+		s.whilePaused(request, s.variables)
+	case "evaluate":
+		s.whilePaused(request, s.evaluate)
+	case "disconnect", "terminate":
+		s.disconnect()
+		s.out.respond(request, nil)
+		return request.Command != "disconnect"
+	default:
+		s.out.fail(request, fmt.Sprintf("Unsupported request '%s'.", request.Command))
+	}
+	return true
+}
+
+type launchArguments struct {
+	Program     string   `json:"program"`
+	Args        []string `json:"args"`
+	StopOnEntry bool     `json:"stopOnEntry"`
+	SearchPath  []string `json:"searchPath"`
+}
+
+// launch loads the script. It starts once the client is done configuring.
+func (s *Server) launch(request request) {
+	var arguments launchArguments
+	if err := json.Unmarshal(request.Arguments, &arguments); err != nil || arguments.Program == "" {
+		s.out.fail(request, "Expect a program to launch.")
+		return
+	}
+
+	source, err := os.ReadFile(arguments.Program)
+	if err != nil {
+		s.out.fail(request, err.Error())
+		return
+	}
+	diagnostics := errors.NewDiagnostics(arguments.Program)
+	tokens, _ := scanner.NewScanner(string(source), diagnostics).ScanTokens()
+	if !diagnostics.HasErrors() {
+		par := parser.NewParser(tokens, diagnostics)
+		s.statements, _ = par.Parse()
+	}
+	if !diagnostics.HasErrors() {
+		res := resolver.NewResolver(s.interpreter, diagnostics)
+		_ = res.Resolve(s.statements)
+	}
+
+	var text bytes.Buffer
+	diagnostics.Text(&text)
+	if diagnostics.HasErrors() {
+		s.out.fail(request, text.String())
+		return
+	}
+	if text.Len() > 0 {
+		s.out.event("output", map[string]any{"category": "console", "output": text.String()})
+	}
+
+	args := make([]any, len(arguments.Args))
+	for i, arg := range arguments.Args {
+		args[i] = arg
+	}
+	s.interpreter.SetFile(arguments.Program)
+	s.interpreter.SetSearchPath(arguments.SearchPath)
+	s.interpreter.Define("args", interpreter.NewList(args))
+	s.stopOnEntry = arguments.StopOnEntry
+	s.launched = true
+	s.out.respond(request, nil)
+}
+
+type setBreakpointsArguments struct {
+	Source struct {
+		Path string `json:"path"`
+	} `json:"source"`
+	Breakpoints []struct {
+		Line int `json:"line"`
+	} `json:"breakpoints"`
+}
+
+// setBreakpoints replaces the breakpoints of a file.
+func (s *Server) setBreakpoints(request request) {
+	var arguments setBreakpointsArguments
+	if err := json.Unmarshal(request.Arguments, &arguments); err != nil || arguments.Source.Path == "" {
+		s.out.fail(request, "Expect a source path.")
+		return
+	}
+
+	s.debugger.ClearBreakpoints(arguments.Source.Path)
+	breakpoints := make([]any, len(arguments.Breakpoints))
+	for i, breakpoint := range arguments.Breakpoints {
+		s.debugger.SetBreakpoint(arguments.Source.Path, breakpoint.Line)
+		breakpoints[i] = map[string]any{"verified": true, "line": breakpoint.Line}
+	}
+	s.out.respond(request, map[string]any{"breakpoints": breakpoints})
+}
+
+func (s *Server) configurationDone(request request) {
+	if !s.launched || s.running {
+		s.out.fail(request, "Expect a launched program.")
+		return
+	}
+	s.out.respond(request, nil)
+
+	s.running = true
+	if s.stopOnEntry {
+		s.debugger.StepInto()
+	}
+	go s.run()
+}
+
+// run runs the script and reports how it ended.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func (s *Server) run() {
+	defer close(s.done)
+
+	exitCode := exitOK
+	if err := s.interpreter.Interpret(s.statements); err != nil && err != debugger.ErrQuit {
+		var text bytes.Buffer
+		_, _ = fmt.Fprintf(&text, "%s\n", err)
+		if err, ok := err.(errors.RuntimeError); ok {
+			for _, frame := range err.Stack() {
+				_, _ = fmt.Fprintf(&text, "  at %s\n", frame)
+			}
+		}
+		s.out.event("output", map[string]any{"category": "stderr", "output": text.String()})
+		exitCode = exitRuntime
+	}
+
+	s.out.event("exited", map[string]any{"exitCode": exitCode})
+	s.out.event("terminated", nil)
+}
+
+// stopped is the handler of the debugger. It tells the client where the
+// script paused, and runs the commands of its requests until one of them
+// resumes the script or the client disconnects.
+func (s *Server) stopped(d *debugger.Debugger, stop debugger.Stop) error {
+	if !s.pause() {
+		return nil
+	}
+	s.out.event("stopped", map[string]any{
+		"reason":            string(stop.Reason),
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+
+	defer func() { s.handles = nil }()
+	for {
+		select {
+		case command := <-s.commands:
+			if command(d) {
+				return nil
+			}
+		case <-s.quit:
+			s.setPaused(false)
+			return nil
+		}
+	}
+}
+
+// pause marks the script paused, unless the client has disconnected.
+func (s *Server) pause() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	select {
+	case <-s.quit:
+		return false
+	default:
+		s.paused = true
+		return true
+	}
+}
+
+func (s *Server) setPaused(paused bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.paused = paused
+}
+
+func (s *Server) isPaused() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.paused
+}
+
+// whilePaused runs the command on the paused script, and waits until it has
+// run. The command responds to the request. A command that resumes the
+// script marks it running before the next request is handled.
+func (s *Server) whilePaused(request request, handle func(request request, d *debugger.Debugger) bool) {
+	if !s.isPaused() {
+		s.out.fail(request, "The script isn't paused.")
+		return
+	}
+
+	ran := make(chan struct{})
+	command := func(d *debugger.Debugger) bool {
+		defer close(ran)
+		if !handle(request, d) {
+			return false
+		}
+		s.setPaused(false)
+		return true
+	}
+	select {
+	case s.commands <- command:
+		<-ran
+	case <-s.done:
+		s.out.fail(request, "The script isn't paused.")
+	}
+}
+
+// resume resumes the paused script as chosen by step.
+func (s *Server) resume(r request, step func(d *debugger.Debugger), body any) {
+	s.whilePaused(r, func(r request, d *debugger.Debugger) bool {
+		step(d)
+		s.out.respond(r, body)
+		return true
+	})
+}
+
+// disconnect stops the script and waits for it to end.
+func (s *Server) disconnect() {
+	if !s.running {
+		return
+	}
+	s.debugger.Quit()
+	s.mutex.Lock()
+	close(s.quit)
+	s.mutex.Unlock()
+	<-s.done
+	s.running = false
+}
+
+func (s *Server) stackTrace(request request, d *debugger.Debugger) bool {
+	stack := d.Stack()
+	frames := make([]any, len(stack))
+	for i, frame := range stack {
+		path, _ := filepath.Abs(frame.File)
+		frames[i] = map[string]any{
+			"id":     i,
+			"name":   frame.Function,
+			"source": map[string]any{"name": filepath.Base(frame.File), "path": path},
+			"line":   frame.Token.Line,
+			"column": frame.Token.Column + 1,
+		}
+	}
+	s.out.respond(request, map[string]any{"stackFrames": frames, "totalFrames": len(frames)})
+	return false
+}
+
+type frameArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+func (s *Server) scopes(request request, d *debugger.Debugger) bool {
+	var arguments frameArguments
+	_ = json.Unmarshal(request.Arguments, &arguments)
+
+	scopes := []any{}
+	for _, scope := range d.Scopes(arguments.FrameID) {
+		name := "Locals"
+		if scope.Name == "globals" {
+			name = "Globals"
+		}
+		scopes = append(scopes, map[string]any{
+			"name":               name,
+			"variablesReference": s.reference(scope.Variables),
+			"expensive":          false,
+		})
+	}
+	s.out.respond(request, map[string]any{"scopes": scopes})
+	return false
+}
+
+type variablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+func (s *Server) variables(request request, d *debugger.Debugger) bool {
+	var arguments variablesArguments
+	_ = json.Unmarshal(request.Arguments, &arguments)
+	if arguments.VariablesReference < 1 || arguments.VariablesReference > len(s.handles) {
+		s.out.fail(request, "Invalid variables reference.")
+		return false
+	}
+
+	variables := []any{}
+	for _, variable := range children(s.handles[arguments.VariablesReference-1], d.Stringify) {
+		variables = append(variables, s.variable(d, variable.Name, variable.Value))
+	}
+	s.out.respond(request, map[string]any{"variables": variables})
+	return false
+}
+
+type evaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId"`
+}
+
+//goland:noinspection GoTypeAssertionOnErrors
+func (s *Server) evaluate(request request, d *debugger.Debugger) bool {
+	var arguments evaluateArguments
+	_ = json.Unmarshal(request.Arguments, &arguments)
+
+	value, err := d.Evaluate(arguments.Expression, arguments.FrameID)
+	if err, ok := err.(errors.RuntimeError); ok {
+		s.out.fail(request, err.Message())
+		return false
+	}
+	if err != nil {
+		s.out.fail(request, err.Error())
+		return false
+	}
+
+	variable := s.variable(d, "", value)
+	s.out.respond(request, map[string]any{
+		"result":             variable["value"],
+		"type":               variable["type"],
+		"variablesReference": variable["variablesReference"],
+	})
+	return false
+}
+
+// variable returns the DAP variable of a value. Values that have children
+// are given a reference to them.
+func (s *Server) variable(d *debugger.Debugger, name string, value any) map[string]any {
+	reference := 0
+	switch value.(type) {
+	case *interpreter.Instance, *interpreter.List, *interpreter.Map:
+		reference = s.reference(value)
+	}
+	return map[string]any{
+		"name":               name,
+		"value":              d.Stringify(value),
+		"type":               interpreter.TypeOf(value),
+		"variablesReference": reference,
+	}
+}
+
+// reference returns a new variables reference to the value.
+func (s *Server) reference(value any) int {
+	s.handles = append(s.handles, value)
+	return len(s.handles)
+}
+
+// outputWriter sends what the script prints as output events.
+type outputWriter struct {
+	out      *writer
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.out.event("output", map[string]any{"category": w.category, "output": string(p)})
+	return len(p), nil
+}
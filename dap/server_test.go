@@ -0,0 +1,356 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const timeout = 5 * time.Second
+
+const program = `class Point {
+  init(x, y) { this.x = x; this.y = y; }
+}
+fun scale(p, k) {
+  var result = Point(p.x * k, p.y * k);
+  return result;
+}
+var origin = Point(1, 2);
+var scaled = scale(origin, 3);
+print scaled.x + scaled.y;
+`
+
+// client drives a server the way an editor would.
+type client struct {
+	t        *testing.T
+	in       io.WriteCloser
+	seq      int
+	messages chan map[string]any
+	// events are the events received while waiting for a response.
+	events []map[string]any
+	served chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, in: clientOut, messages: make(chan map[string]any, 100), served: make(chan error, 1)}
+	go func() {
+		c.served <- NewServer(serverIn, serverOut).Serve()
+		_ = serverOut.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientIn)
+		for {
+			content, err := readMessage(reader)
+			if err != nil {
+				return
+			}
+			var message map[string]any
+			if err := json.Unmarshal(content, &message); err != nil {
+				t.Errorf("invalid message %s", content)
+				return
+			}
+			c.messages <- message
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = c.in.Close()
+	})
+	return c
+}
+
+func (c *client) next() map[string]any {
+	c.t.Helper()
+	select {
+	case message, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("server closed the connection")
+		}
+		return message
+	case <-time.After(timeout):
+		c.t.Fatal("timed out waiting for a message")
+	}
+	return nil
+}
+
+// request sends a request and returns its response.
+func (c *client) request(command string, arguments any) map[string]any {
+	c.t.Helper()
+	c.seq++
+	content, _ := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(content), content); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		message := c.next()
+		if message["type"] == "event" {
+			c.events = append(c.events, message)
+			continue
+		}
+		if message["request_seq"] != float64(c.seq) || message["command"] != command {
+			c.t.Fatalf("unexpected response %v to %s", message, command)
+		}
+		return message
+	}
+}
+
+// body sends a request that must succeed and returns the body of its
+// response.
+func (c *client) body(command string, arguments any) map[string]any {
+	c.t.Helper()
+	response := c.request(command, arguments)
+	if response["success"] != true {
+		c.t.Fatalf("%s failed: %v", command, response["message"])
+	}
+	body, _ := response["body"].(map[string]any)
+	return body
+}
+
+// event waits for the named event and returns its body. Other events before
+// it are skipped.
+func (c *client) event(name string) map[string]any {
+	c.t.Helper()
+	for {
+		var message map[string]any
+		if len(c.events) > 0 {
+			message, c.events = c.events[0], c.events[1:]
+		} else {
+			message = c.next()
+		}
+		if message["type"] == "event" && message["event"] == name {
+			body, _ := message["body"].(map[string]any)
+			return body
+		}
+	}
+}
+
+// start launches the program and waits until it is running.
+func (c *client) start(source string, arguments map[string]any, breakpoints ...int) string {
+	c.t.Helper()
+	path := filepath.Join(c.t.TempDir(), "program.g")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		c.t.Fatal(err)
+	}
+
+	c.body("initialize", map[string]any{"adapterID": "interp"})
+	c.event("initialized")
+	arguments["program"] = path
+	c.body("launch", arguments)
+
+	lines := make([]any, len(breakpoints))
+	for i, line := range breakpoints {
+		lines[i] = map[string]any{"line": line}
+	}
+	body := c.body("setBreakpoints", map[string]any{"source": map[string]any{"path": path}, "breakpoints": lines})
+	if got := len(body["breakpoints"].([]any)); got != len(breakpoints) {
+		c.t.Fatalf("got %d breakpoints, want %d", got, len(breakpoints))
+	}
+
+	c.body("configurationDone", nil)
+	return path
+}
+
+// stopped waits until the script pauses, and checks why and where.
+func (c *client) stopped(reason string, function string, line int) {
+	c.t.Helper()
+	if got := c.event("stopped")["reason"]; got != reason {
+		c.t.Fatalf("stopped because of %v, want %s", got, reason)
+	}
+	frame := c.stack()[0]
+	if frame["name"] != function || frame["line"] != float64(line) {
+		c.t.Fatalf("stopped in %v at line %v, want %s at line %d", frame["name"], frame["line"], function, line)
+	}
+}
+
+func (c *client) stack() []map[string]any {
+	c.t.Helper()
+	var frames []map[string]any
+	for _, frame := range c.body("stackTrace", map[string]any{"threadId": threadID})["stackFrames"].([]any) {
+		frames = append(frames, frame.(map[string]any))
+	}
+	return frames
+}
+
+// variables returns the values of the variables under a reference by name,
+// and their references.
+func (c *client) variables(reference any) (map[string]string, map[string]any) {
+	c.t.Helper()
+	values := map[string]string{}
+	references := map[string]any{}
+	for _, variable := range c.body("variables", map[string]any{"variablesReference": reference})["variables"].([]any) {
+		variable := variable.(map[string]any)
+		name := variable["name"].(string)
+		values[name] = variable["value"].(string)
+		references[name] = variable["variablesReference"]
+	}
+	return values, references
+}
+
+func (c *client) evaluate(expression string, frame int) string {
+	c.t.Helper()
+	return c.body("evaluate", map[string]any{"expression": expression, "frameId": frame})["result"].(string)
+}
+
+// finished waits until the script has ended and checks its exit code.
+func (c *client) finished(exitCode int) {
+	c.t.Helper()
+	if got := c.event("exited")["exitCode"]; got != float64(exitCode) {
+		c.t.Fatalf("exited with %v, want %d", got, exitCode)
+	}
+	c.event("terminated")
+	c.body("disconnect", nil)
+	select {
+	case err := <-c.served:
+		if err != nil {
+			c.t.Fatal(err)
+		}
+	case <-time.After(timeout):
+		c.t.Fatal("server didn't stop after disconnect")
+	}
+}
+
+func TestBreakpointsAndInspection(t *testing.T) {
+	c := newClient(t)
+	c.start(program, map[string]any{}, 5)
+	c.stopped("breakpoint", "scale", 5)
+
+	stack := c.stack()
+	if len(stack) != 2 || stack[1]["name"] != "script" || stack[1]["line"] != float64(9) {
+		t.Fatalf("unexpected stack %v", stack)
+	}
+
+	scopes := c.body("scopes", map[string]any{"frameId": 0})["scopes"].([]any)
+	if len(scopes) != 2 {
+		t.Fatalf("got %d scopes, want locals and globals", len(scopes))
+	}
+	locals := scopes[0].(map[string]any)
+	values, references := c.variables(locals["variablesReference"])
+	if values["k"] != "3" || values["p"] != "Point instance" {
+		t.Fatalf("unexpected locals %v", values)
+	}
+	fields, _ := c.variables(references["p"])
+	if fields["x"] != "1" || fields["y"] != "2" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	globals, _ := c.variables(scopes[1].(map[string]any)["variablesReference"])
+	if globals["origin"] != "Point instance" || globals["scale"] != "<fn scale>" {
+		t.Fatalf("unexpected globals %v", globals)
+	}
+
+	if got := c.evaluate("p.x * k", 0); got != "3" {
+		t.Fatalf("p.x * k = %s", got)
+	}
+	if got := c.evaluate("origin.y", 1); got != "2" {
+		t.Fatalf("origin.y = %s", got)
+	}
+	response := c.request("evaluate", map[string]any{"expression": "missing", "frameId": 0})
+	if response["success"] != false || !strings.Contains(response["message"].(string), "Undefined variable 'missing'.") {
+		t.Fatalf("unexpected response %v", response)
+	}
+
+	c.body("next", map[string]any{"threadId": threadID})
+	c.stopped("step", "scale", 6)
+	c.body("stepOut", map[string]any{"threadId": threadID})
+	c.stopped("step", "script", 10)
+
+	c.body("continue", map[string]any{"threadId": threadID})
+	if output := c.event("output"); output["category"] != "stdout" || output["output"] != "9\n" {
+		t.Fatalf("unexpected output %v", output)
+	}
+	c.finished(0)
+}
+
+func TestSteppingFromEntry(t *testing.T) {
+	c := newClient(t)
+	c.start(program, map[string]any{"stopOnEntry": true})
+	c.stopped("entry", "script", 1)
+
+	c.body("next", map[string]any{"threadId": threadID})
+	c.stopped("step", "script", 4)
+	c.body("next", map[string]any{"threadId": threadID})
+	c.stopped("step", "script", 8)
+	c.body("stepIn", map[string]any{"threadId": threadID})
+	c.stopped("step", "init", 2)
+	c.body("stepOut", map[string]any{"threadId": threadID})
+	c.stopped("step", "script", 9)
+	c.body("stepIn", map[string]any{"threadId": threadID})
+	c.stopped("step", "scale", 5)
+
+	c.body("continue", map[string]any{"threadId": threadID})
+	c.finished(0)
+}
+
+func TestRuntimeError(t *testing.T) {
+	c := newClient(t)
+	c.start("fun f() {\n  return nil.x;\n}\nf();\n", map[string]any{})
+
+	output := c.event("output")
+	text, _ := output["output"].(string)
+	if output["category"] != "stderr" || !strings.Contains(text, "Only instances have properties.") || !strings.Contains(text, "at f (") {
+		t.Fatalf("unexpected output %v", output)
+	}
+	c.finished(70)
+}
+
+func TestRequestsThatNeedAPausedScript(t *testing.T) {
+	c := newClient(t)
+	c.body("initialize", nil)
+	if response := c.request("stackTrace", map[string]any{"threadId": threadID}); response["success"] != false {
+		t.Fatalf("stackTrace succeeded without a script: %v", response)
+	}
+	if response := c.request("configurationDone", nil); response["success"] != false {
+		t.Fatalf("configurationDone succeeded without a launch: %v", response)
+	}
+	c.body("disconnect", nil)
+}
+
+func TestDisconnectWhilePaused(t *testing.T) {
+	c := newClient(t)
+	c.start(program, map[string]any{}, 2)
+	c.stopped("breakpoint", "init", 2)
+	c.body("disconnect", nil)
+
+	select {
+	case err := <-c.served:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(timeout):
+		t.Fatal("server didn't stop after disconnect")
+	}
+}
+
+func TestRequestsWhileRunning(t *testing.T) {
+	c := newClient(t)
+	c.start("var i = 0;\nwhile (true) {\n  i = i + 1;\n}\n", map[string]any{}, 1)
+	c.stopped("breakpoint", "script", 1)
+
+	// The script is running once continue has responded, so requests that
+	// need it paused fail instead of waiting for it.
+	c.body("continue", map[string]any{"threadId": threadID})
+	if response := c.request("stackTrace", map[string]any{"threadId": threadID}); response["success"] != false {
+		t.Fatalf("stackTrace succeeded while running: %v", response)
+	}
+
+	c.body("disconnect", nil)
+	select {
+	case err := <-c.served:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(timeout):
+		t.Fatal("server didn't stop after disconnect")
+	}
+}
@@ -0,0 +1,47 @@
+package dap
+
+import (
+	"interp/debugger"
+	"interp/interpreter"
+	"sort"
+	"strconv"
+)
+
+// children returns the variables shown under a variables reference: those of
+// a scope, the fields of an instance, or the elements of a list or map.
+func children(value any, stringify func(any) string) []debugger.Variable {
+	var variables []debugger.Variable
+	switch value := value.(type) {
+	case []debugger.Variable:
+		variables = value
+	case *interpreter.Instance:
+		fields := value.Fields()
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			variables = append(variables, debugger.Variable{Name: name, Value: fields[name]})
+		}
+	case *interpreter.List:
+		for index, element := range value.Elements() {
+			variables = append(variables, debugger.Variable{Name: strconv.Itoa(index), Value: element})
+		}
+	case *interpreter.Map:
+		for _, key := range value.Keys() {
+			element, _ := value.Get(key)
+			variables = append(variables, debugger.Variable{Name: mapKey(key, stringify), Value: element})
+		}
+	}
+	return variables
+}
+
+// mapKey returns the name a map entry is shown with: its key, quoted if it
+// is a string.
+func mapKey(key any, stringify func(any) string) string {
+	if key, ok := key.(string); ok {
+		return strconv.Quote(key)
+	}
+	return stringify(key)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/samber/lo"
+	"interp/debugger"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/resolver"
+	"os"
+	"path/filepath"
+)
+
+// debug runs a script with the tree-walking interpreter, paused before its
+// first statement in the debugger console.
+func debug(arguments []string) int {
+	flags := flag.NewFlagSet("interp debug", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+	searchPath := flags.String("path", os.Getenv("INTERP_PATH"), "where imported modules are looked for")
+	if err := flags.Parse(arguments); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	file := flags.Arg(0)
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Println(err)
+		return exitIO
+	}
+	source := string(bytes)
+
+	diagnostics := errors.NewDiagnostics(file)
+	statements := parse(source, diagnostics)
+	if diagnostics.HasErrors() {
+		report(diagnostics, source, formatPretty)
+		return exitSyntax
+	}
+
+	inter := interpreter.NewInterpreter()
+	inter.SetFile(file)
+	inter.SetSearchPath(filepath.SplitList(*searchPath))
+	inter.Define("args", interpreter.NewList(lo.ToAnySlice(flags.Args()[1:])))
+
+	res := resolver.NewResolver(&inter, diagnostics)
+	if err := res.Resolve(statements); err != nil {
+		fmt.Println(err)
+	}
+	report(diagnostics, source, formatPretty)
+	if diagnostics.HasErrors() {
+		return exitResolve
+	}
+
+	console := debugger.NewConsole(os.Stdin, os.Stdout)
+	console.SetSource(file, source)
+	d := debugger.New(&inter, console.Stopped)
+	d.StepInto()
+
+	err = inter.Interpret(statements)
+	if err == debugger.ErrQuit {
+		return exitOK
+	}
+	code := reportRuntime(err, source, file, formatPretty)
+	if code == exitOK {
+		fmt.Println("Script finished.")
+	}
+	return code
+}
@@ -0,0 +1,196 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"interp/errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const consoleHelp = `Commands:
+  c, continue          run until the next breakpoint
+  s, step              step to the next line, into calls
+  n, next              step to the next line, over calls
+  o, out               run until the current function returns
+  b, break [file:]line set a breakpoint; without a line, list them
+  d, delete [file:]line
+                       clear a breakpoint
+  bt, stack            show the call stack
+  v, vars              show the variables in scope
+  p, print <expr>      evaluate an expression where the script is paused
+  l, list              show the source around the current line
+  q, quit              stop the script
+  h, help              show this help
+An empty line repeats the last command.
+`
+
+// Console is a command line interface to a debugger. It shows where the
+// script paused and reads commands until one of them resumes it.
+type Console struct {
+	in  *bufio.Scanner
+	out io.Writer
+	// sources are the sources of files by name, for those that aren't read
+	// from disk.
+	sources map[string]string
+	last    string
+}
+
+func NewConsole(in io.Reader, out io.Writer) *Console {
+	return &Console{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		sources: map[string]string{},
+	}
+}
+
+// SetSource sets the source of a file, so that it isn't read from disk.
+func (c *Console) SetSource(file string, source string) {
+	c.sources[file] = source
+}
+
+// Stopped is the Handler of the console.
+func (c *Console) Stopped(d *Debugger, stop Stop) error {
+	c.printf("Paused at %s:%d (%s)\n", stop.File, stop.Line, stop.Reason)
+	c.printLines(stop.File, stop.Line, 0)
+
+	for {
+		c.printf("(debug) ")
+		if !c.in.Scan() {
+			d.Quit()
+			return nil
+		}
+
+		line := strings.TrimSpace(c.in.Text())
+		if line == "" {
+			line = c.last
+		}
+		c.last = line
+
+		command, argument, _ := strings.Cut(line, " ")
+		argument = strings.TrimSpace(argument)
+		switch command {
+		case "":
+		case "c", "continue":
+			d.Continue()
+			return nil
+		case "s", "step":
+			d.StepInto()
+			return nil
+		case "n", "next":
+			d.StepOver()
+			return nil
+		case "o", "out":
+			d.StepOut()
+			return nil
+		case "q", "quit":
+			d.Quit()
+			return nil
+		case "b", "break":
+			c.breakpoint(d, stop, argument, true)
+		case "d", "delete":
+			c.breakpoint(d, stop, argument, false)
+		case "bt", "stack":
+			for index, frame := range d.Stack() {
+				c.printf("#%d %s\n", index, frame)
+			}
+		case "v", "vars":
+			c.variables(d)
+		case "p", "print":
+			value, err := d.Evaluate(argument, 0)
+			if err != nil {
+				c.printError(err)
+			} else {
+				c.printf("%s\n", d.Stringify(value))
+			}
+		case "l", "list":
+			c.printLines(stop.File, stop.Line, 5)
+		case "h", "help":
+			c.printf("%s", consoleHelp)
+		default:
+			c.printf("Unknown command '%s'. Type 'help' for a list of commands.\n", command)
+		}
+	}
+}
+
+// breakpoint sets or clears the breakpoint at [file:]line. Without a file it
+// is in the file the script is paused in.
+func (c *Console) breakpoint(d *Debugger, stop Stop, argument string, set bool) {
+	if argument == "" {
+		if set {
+			for _, breakpoint := range d.Breakpoints() {
+				c.printf("%s:%d\n", breakpoint.File, breakpoint.Line)
+			}
+			return
+		}
+		c.printf("Expect a line.\n")
+		return
+	}
+
+	file := stop.File
+	if index := strings.LastIndex(argument, ":"); index >= 0 {
+		file, argument = argument[:index], argument[index+1:]
+	}
+	line, err := strconv.Atoi(argument)
+	if err != nil || line < 1 {
+		c.printf("Invalid line '%s'.\n", argument)
+		return
+	}
+
+	if set {
+		d.SetBreakpoint(file, line)
+		c.printf("Breakpoint at %s:%d.\n", file, line)
+	} else {
+		d.ClearBreakpoint(file, line)
+		c.printf("Cleared breakpoint at %s:%d.\n", file, line)
+	}
+}
+
+func (c *Console) variables(d *Debugger) {
+	for _, scope := range d.Scopes(0) {
+		if len(scope.Variables) == 0 {
+			continue
+		}
+		c.printf("%s:\n", scope.Name)
+		for _, variable := range scope.Variables {
+			c.printf("  %s = %s\n", variable.Name, d.Stringify(variable.Value))
+		}
+	}
+}
+
+// printLines prints the lines of the file around line, marking line.
+func (c *Console) printLines(file string, line int, context int) {
+	source, ok := c.sources[file]
+	if !ok {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return
+		}
+		source = string(contents)
+		c.sources[file] = source
+	}
+
+	lines := strings.Split(source, "\n")
+	for number := max(1, line-context); number <= min(len(lines), line+context); number++ {
+		marker := " "
+		if number == line {
+			marker = ">"
+		}
+		c.printf("%s %4d  %s\n", marker, number, lines[number-1])
+	}
+}
+
+//goland:noinspection GoTypeAssertionOnErrors
+func (c *Console) printError(err error) {
+	if err, ok := err.(errors.RuntimeError); ok {
+		c.printf("Runtime error: %s\n", err.Message())
+		return
+	}
+	c.printf("Error: %s\n", err)
+}
+
+func (c *Console) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(c.out, format, args...)
+}
@@ -0,0 +1,368 @@
+// Package debugger pauses scripts run by the tree-walking interpreter at
+// breakpoints and after steps, so that their call stack and variables can be
+// inspected.
+//
+//	d := debugger.New(&inter, func(d *debugger.Debugger, stop debugger.Stop) error {
+//		fmt.Println("paused at line", stop.Line)
+//		d.StepOver()
+//		return nil
+//	})
+//	d.StepInto()
+//	err := inter.Interpret(statements)
+package debugger
+
+import (
+	"fmt"
+	"interp/ast"
+	"interp/environment"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/parser"
+	"interp/resolver"
+	"interp/scanner"
+	"interp/token"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Reason is why a script paused.
+type Reason string
+
+const (
+	ReasonEntry      Reason = "entry"
+	ReasonStep       Reason = "step"
+	ReasonBreakpoint Reason = "breakpoint"
+)
+
+// Stop is a statement that a script paused before.
+type Stop struct {
+	Reason Reason
+	File   string
+	Line   int
+	// Depth is the number of calls of script functions in progress.
+	Depth int
+}
+
+// Handler is called when a script pauses. The script goes on once it
+// returns, as chosen by calling Continue or one of the steps; if none is
+// called it steps into the next statement. An error it returns stops the
+// script.
+type Handler func(d *Debugger, stop Stop) error
+
+// ErrQuit is returned by Pause once Quit was called. Scripts can't catch it.
+var ErrQuit = quitError{}
+
+type quitError struct{}
+
+func (quitError) Error() string {
+	return "Quit the debugger."
+}
+
+// Breakpoint is a line of a file that scripts pause at. File is an absolute
+// path.
+type Breakpoint struct {
+	File string
+	Line int
+}
+
+// Scope is an environment of the paused code.
+type Scope struct {
+	Name      string
+	Variables []Variable
+}
+
+type Variable struct {
+	Name  string
+	Value any
+}
+
+type mode int
+
+const (
+	modeContinue mode = iota
+	modeStepInto
+	modeStepOver
+	modeStepOut
+)
+
+// position is where a statement is, and how deep in calls it ran.
+type position struct {
+	file  string
+	line  int
+	depth int
+}
+
+// Debugger is the interpreter.Debugger that pauses an interpreter. Its
+// breakpoints can be changed and Quit called from other goroutines while the
+// script runs; everything else must only be used by the handler.
+type Debugger struct {
+	interpreter *interpreter.Interpreter
+	handler     Handler
+	// mutex guards the breakpoints and the absolute paths.
+	mutex       sync.Mutex
+	breakpoints map[Breakpoint]bool
+	mode        mode
+	// from is where the current step started.
+	from position
+	// last is where the last statement that ran is.
+	last position
+	// at is the start of the statement the script is paused before.
+	at      token.Token
+	started bool
+	quit    atomic.Bool
+	// absolute caches the absolute paths of the interpreter's file names.
+	absolute map[string]string
+}
+
+// New returns a debugger for the interpreter and makes it its debugger. The
+// script runs until a breakpoint, unless StepInto is called before it starts,
+// in which case it pauses before the first statement.
+func New(inter *interpreter.Interpreter, handler Handler) *Debugger {
+	d := &Debugger{
+		interpreter: inter,
+		handler:     handler,
+		breakpoints: map[Breakpoint]bool{},
+		from:        position{depth: -1},
+		absolute:    map[string]string{},
+	}
+	inter.SetDebugger(d)
+	return d
+}
+
+func (d *Debugger) SetBreakpoint(file string, line int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.breakpoints[Breakpoint{d.abs(file), line}] = true
+}
+
+func (d *Debugger) ClearBreakpoint(file string, line int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.breakpoints, Breakpoint{d.abs(file), line})
+}
+
+// ClearBreakpoints clears the breakpoints in the file.
+func (d *Debugger) ClearBreakpoints(file string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	file = d.abs(file)
+	for breakpoint := range d.breakpoints {
+		if breakpoint.File == file {
+			delete(d.breakpoints, breakpoint)
+		}
+	}
+}
+
+// Breakpoints returns the breakpoints sorted by file and line.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	breakpoints := make([]Breakpoint, 0, len(d.breakpoints))
+	for breakpoint := range d.breakpoints {
+		breakpoints = append(breakpoints, breakpoint)
+	}
+	sort.Slice(breakpoints, func(i, j int) bool {
+		if breakpoints[i].File != breakpoints[j].File {
+			return breakpoints[i].File < breakpoints[j].File
+		}
+		return breakpoints[i].Line < breakpoints[j].Line
+	})
+	return breakpoints
+}
+
+// Continue runs the script until the next breakpoint.
+func (d *Debugger) Continue() {
+	d.mode = modeContinue
+}
+
+// StepInto runs the script until the next line, which may be in a function
+// that is called.
+func (d *Debugger) StepInto() {
+	d.step(modeStepInto)
+}
+
+// StepOver runs the script until the next line of the current function, or
+// the function it returns to.
+func (d *Debugger) StepOver() {
+	d.step(modeStepOver)
+}
+
+// StepOut runs the script until the current function returns.
+func (d *Debugger) StepOut() {
+	d.step(modeStepOut)
+}
+
+func (d *Debugger) step(mode mode) {
+	d.mode = mode
+	if d.started {
+		d.from = d.last
+	}
+}
+
+// Quit stops the script before its next statement, or once the handler
+// returns if it is paused. It can be called from other goroutines.
+func (d *Debugger) Quit() {
+	d.quit.Store(true)
+}
+
+// Pause pauses the script before the statement if it is at a breakpoint or
+// the end of a step. Blocks are skipped, the statements in them pause.
+func (d *Debugger) Pause(stmt ast.Stmt) error {
+	if d.quit.Load() {
+		return ErrQuit
+	}
+	if _, ok := stmt.(*ast.BlockStmt); ok {
+		return nil
+	}
+
+	at := ast.StmtStart(stmt)
+	d.mutex.Lock()
+	current := position{d.abs(d.interpreter.File()), at.Line, d.interpreter.Depth()}
+	previous := d.last
+	d.last = current
+	reason, ok := d.shouldStop(current, previous)
+	d.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	if !d.started && reason == ReasonStep {
+		reason = ReasonEntry
+	}
+	d.started = true
+
+	d.at = at
+	d.from = current
+	d.mode = modeStepInto
+	err := d.handler(d, Stop{reason, d.interpreter.File(), at.Line, current.depth})
+	if err == nil && d.quit.Load() {
+		err = ErrQuit
+	}
+	return err
+}
+
+func (d *Debugger) shouldStop(current position, previous position) (Reason, bool) {
+	sameLine := current.file == d.from.file && current.line == d.from.line
+	switch {
+	case d.mode == modeStepInto && (current.depth != d.from.depth || !sameLine):
+		return ReasonStep, true
+	case d.mode == modeStepOver && (current.depth < d.from.depth || current.depth == d.from.depth && !sameLine):
+		return ReasonStep, true
+	case d.mode == modeStepOut && current.depth < d.from.depth:
+		return ReasonStep, true
+	}
+
+	// A line with several statements only pauses at the first of them.
+	if d.breakpoints[Breakpoint{current.file, current.line}] && current != previous {
+		return ReasonBreakpoint, true
+	}
+	return "", false
+}
+
+// Stack returns the calls in progress, innermost first. The innermost one is
+// at the statement the script is paused before.
+func (d *Debugger) Stack() []errors.StackFrame {
+	return d.interpreter.Stack(d.at)
+}
+
+// Scopes returns the environments of a call in progress, the index of which
+// is its index in Stack. They are innermost first: its locals, then the
+// globals of the file it is in. Natives are left out of the globals.
+func (d *Debugger) Scopes(frame int) []Scope {
+	environments := d.interpreter.Environments()
+	if frame < 0 || frame >= len(environments) {
+		return nil
+	}
+
+	var scopes []Scope
+	for env := environments[frame]; env != nil; env = env.Enclosing() {
+		scope := Scope{Name: "locals"}
+		if env.IsGlobal() {
+			scope.Name = "globals"
+		}
+		for _, name := range env.Names() {
+			value, _ := env.Value(name)
+			switch value.(type) {
+			case *interpreter.Native, interpreter.Clock:
+				continue
+			}
+			scope.Variables = append(scope.Variables, Variable{name, value})
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// Evaluate evaluates an expression in the environment of a call in progress,
+// the index of which is its index in Stack. Runtime errors are returned as
+// errors.RuntimeError.
+func (d *Debugger) Evaluate(source string, frame int) (any, error) {
+	environments := d.interpreter.Environments()
+	if frame < 0 || frame >= len(environments) {
+		return nil, fmt.Errorf("no frame %d", frame)
+	}
+	env := environments[frame]
+
+	diagnostics := errors.NewDiagnostics("")
+	tokens, _ := scanner.NewScanner(source, diagnostics).ScanTokens()
+	if err := firstError(diagnostics); err != nil {
+		return nil, err
+	}
+
+	par := parser.NewParser(tokens, diagnostics)
+	expr, err := par.ParseExpression()
+	if err := firstError(diagnostics); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := resolver.NewResolver(d.interpreter, diagnostics)
+	if err := res.ResolveExprIn(expr, scopeNames(env)); err != nil {
+		return nil, err
+	}
+	if err := firstError(diagnostics); err != nil {
+		return nil, err
+	}
+
+	return d.interpreter.Evaluate(expr, env)
+}
+
+// Stringify returns the value as print would show it.
+func (d *Debugger) Stringify(value any) string {
+	return d.interpreter.Stringify(value)
+}
+
+// abs returns the absolute path of the file. The mutex must be held.
+func (d *Debugger) abs(file string) string {
+	if absolute, ok := d.absolute[file]; ok {
+		return absolute
+	}
+	absolute, err := filepath.Abs(file)
+	if err != nil {
+		absolute = file
+	}
+	d.absolute[file] = absolute
+	return absolute
+}
+
+// scopeNames returns the names of the variables of the local environments,
+// outermost first, as the resolver expects them.
+func scopeNames(env *environment.Environment) [][]string {
+	var scopes [][]string
+	for ; env != nil && !env.IsGlobal(); env = env.Enclosing() {
+		scopes = append([][]string{env.Names()}, scopes...)
+	}
+	return scopes
+}
+
+func firstError(diagnostics *errors.Diagnostics) error {
+	for _, diagnostic := range diagnostics.Items() {
+		if diagnostic.Severity == errors.SeverityError {
+			return fmt.Errorf("%s", diagnostic.Message)
+		}
+	}
+	return nil
+}
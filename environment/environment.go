@@ -4,22 +4,85 @@ import (
 	"fmt"
 	"interp/errors"
 	"interp/token"
+	"sort"
 )
 
+// Environment holds the variables of a scope. The outermost environment holds
+// the globals, keyed by name. All others hold locals in slots, in the order
+// they were defined, which is the order the resolver assigned the slots in.
 type Environment struct {
 	enclosing *Environment
-	values    map[string]any
+	slots     []any
+	// names are the names of the slots, for debuggers.
+	names   []string
+	globals map[string]any
+	// root is the outermost environment.
+	root *Environment
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
-	return &Environment{
-		enclosing: enclosing,
-		values:    map[string]any{},
+	if enclosing == nil {
+		environment := &Environment{globals: map[string]any{}}
+		environment.root = environment
+		return environment
 	}
+	return &Environment{enclosing: enclosing, root: enclosing.root}
 }
 
+func (e *Environment) Enclosing() *Environment {
+	return e.enclosing
+}
+
+// Define defines a variable. In the global environment it is found by its
+// name; elsewhere it takes the next slot and the name is only informative.
 func (e *Environment) Define(name string, value any) {
-	e.values[name] = value
+	if e.globals != nil {
+		e.globals[name] = value
+		return
+	}
+	e.slots = append(e.slots, value)
+	e.names = append(e.names, name)
+}
+
+// IsGlobal reports whether the environment is the one that holds the
+// globals.
+func (e *Environment) IsGlobal() bool {
+	return e.globals != nil
+}
+
+// Names returns the names of the variables defined in the environment. Locals
+// are in slot order, globals sorted by name.
+func (e *Environment) Names() []string {
+	if e.globals == nil {
+		return e.names
+	}
+	names := make([]string, 0, len(e.globals))
+	for name := range e.globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value returns the value of the variable with the given name defined in the
+// environment itself.
+func (e *Environment) Value(name string) (any, bool) {
+	if e.globals != nil {
+		value, ok := e.globals[name]
+		return value, ok
+	}
+	for slot, slotName := range e.names {
+		if slotName == name {
+			return e.slots[slot], true
+		}
+	}
+	return nil, false
+}
+
+// Lookup returns the value of a global variable.
+func (e *Environment) Lookup(name string) (any, bool) {
+	value, ok := e.root.globals[name]
+	return value, ok
 }
 
 func (e *Environment) ancestor(distance int) *Environment {
@@ -30,35 +93,29 @@ func (e *Environment) ancestor(distance int) *Environment {
 	return environment
 }
 
-func (e *Environment) GetAt(distance int, name string) any {
-	return e.ancestor(distance).values[name]
+func (e *Environment) GetAt(distance int, slot int) any {
+	return e.ancestor(distance).slots[slot]
 }
 
-func (e *Environment) AssignAt(distance int, name token.Token, value any) {
-	e.ancestor(distance).values[name.Lexeme] = value
+func (e *Environment) AssignAt(distance int, slot int, value any) {
+	e.ancestor(distance).slots[slot] = value
 }
 
+// Get returns the value of a global variable.
 func (e *Environment) Get(name token.Token) (any, error) {
-	if value, ok := e.values[name.Lexeme]; ok {
+	if value, ok := e.root.globals[name.Lexeme]; ok {
 		return value, nil
 	}
 
-	if e.enclosing != nil {
-		return e.enclosing.Get(name)
-	}
-
 	return nil, errors.NewRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.Lexeme))
 }
 
+// Assign assigns to a global variable.
 func (e *Environment) Assign(name token.Token, value any) error {
-	if _, ok := e.values[name.Lexeme]; ok {
-		e.values[name.Lexeme] = value
+	if _, ok := e.root.globals[name.Lexeme]; ok {
+		e.root.globals[name.Lexeme] = value
 		return nil
 	}
 
-	if e.enclosing != nil {
-		return e.enclosing.Assign(name, value)
-	}
-
 	return errors.NewRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.Lexeme))
 }
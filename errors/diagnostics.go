@@ -0,0 +1,128 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"interp/token"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is an error or a warning found in a source file. Lines and
+// columns start at 1. Span is the length of the offending source text.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Span     int      `json:"span"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	position := fmt.Sprintf("%d:%d", d.Line, d.Column)
+	if d.File != "" {
+		position = d.File + ":" + position
+	}
+	return fmt.Sprintf("%s: %s: %s", position, d.Severity, d.Message)
+}
+
+// Diagnostics collects the errors and warnings found while scanning, parsing
+// and resolving a file.
+type Diagnostics struct {
+	file  string
+	items []Diagnostic
+}
+
+func NewDiagnostics(file string) *Diagnostics {
+	return &Diagnostics{file: file}
+}
+
+func (d *Diagnostics) Error(token token.Token, message string) {
+	d.add(SeverityError, token, message)
+}
+
+func (d *Diagnostics) Warning(token token.Token, message string) {
+	d.add(SeverityWarning, token, message)
+}
+
+// ErrorAt reports an error that doesn't belong to a token. The column starts
+// at 0, like token columns do.
+func (d *Diagnostics) ErrorAt(line int, column int, span int, message string) {
+	d.items = append(d.items, Diagnostic{
+		Severity: SeverityError,
+		File:     d.file,
+		Line:     line,
+		Column:   column + 1,
+		Span:     max(span, 1),
+		Message:  message,
+	})
+}
+
+func (d *Diagnostics) add(severity Severity, token token.Token, message string) {
+	d.items = append(d.items, Diagnostic{
+		Severity: severity,
+		File:     d.file,
+		Line:     token.Line,
+		Column:   token.Column + 1,
+		Span:     max(utf8.RuneCountInString(token.Lexeme), 1),
+		Message:  message,
+	})
+}
+
+func (d *Diagnostics) HasErrors() bool {
+	for _, item := range d.items {
+		if item.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Items returns the collected diagnostics ordered by their position.
+func (d *Diagnostics) Items() []Diagnostic {
+	items := make([]Diagnostic, len(d.items))
+	copy(items, d.items)
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Line != items[j].Line {
+			return items[i].Line < items[j].Line
+		}
+		return items[i].Column < items[j].Column
+	})
+	return items
+}
+
+// Print writes every diagnostic as a coloured snippet of the source.
+func (d *Diagnostics) Print(w io.Writer, source string) {
+	for _, item := range d.Items() {
+		colour, label := red, "Error: "
+		if item.Severity == SeverityWarning {
+			colour, label = yellow, "Warning: "
+		}
+		printSnippet(w, source, item.Line, item.Column, item.Span, colour, label+item.Message)
+	}
+}
+
+// Text writes every diagnostic on its own line as plain text.
+func (d *Diagnostics) Text(w io.Writer) {
+	for _, item := range d.Items() {
+		_, _ = fmt.Fprintln(w, item.String())
+	}
+}
+
+// JSON writes the diagnostics as a JSON array.
+func (d *Diagnostics) JSON(w io.Writer) error {
+	items := d.Items()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(items)
+}
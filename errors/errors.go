@@ -2,22 +2,115 @@ package errors
 
 import (
 	"fmt"
-	"interp/token"
+	"io"
+	"strings"
+	"unicode"
 )
 
 const lineCount = 2
 
 const red = "\033[0;31m"
+const yellow = "\033[0;33m"
 const grey = "\033[0;37m"
 const none = "\033[0m"
 
-var HadError = false
+// printSnippet prints the lines around line, with a marker under the span
+// starting at column. Lines and columns start at 1.
+func printSnippet(w io.Writer, source string, line int, column int, span int, colour string, message string) {
+	lines := strings.Split(source, "\n")
+	index := line - 1
 
-func Error(token token.Token, message string) {
-	HadError = true
-	fmt.Printf("[line %d] %s\n", token.Line, message)
+	var newLines []string
+
+	start := max(0, index-lineCount)
+	end := min(index+1+lineCount, len(lines))
+
+	for i := start; i < end; i++ {
+		number := fmt.Sprintf("%d", i+1)
+		newLines = append(newLines, grey+number+none+" "+lines[i])
+		if i == index {
+			spaces := strings.Repeat(" ", len(number)+1) + indent(lines[i], column)
+			marker := "^" + strings.Repeat("~", max(0, spanWidth(lines[i], column, span)-1))
+			newLines = append(newLines, colour+spaces+marker+" "+message+none)
+		}
+	}
+
+	_, _ = fmt.Fprintln(w, strings.Join(newLines, "\n"))
+}
+
+// printLine prints a single line of the source, indented under a stack
+// frame, with a marker at column.
+func printLine(w io.Writer, source string, line int, column int) {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		return
+	}
+
+	number := fmt.Sprintf("%d", line)
+	spaces := strings.Repeat(" ", len(number)+1) + indent(lines[line-1], column)
+	_, _ = fmt.Fprintf(w, "    %s%s%s %s\n", grey, number, none, lines[line-1])
+	_, _ = fmt.Fprintf(w, "    %s%s^%s\n", red, spaces, none)
+}
+
+// indent returns what goes under a line of source before a marker at the
+// column, which counts characters: tabs are kept, so that the marker lines up
+// however they are shown, and wide characters take two spaces.
+func indent(line string, column int) string {
+	var out strings.Builder
+	for i, c := range []rune(line) {
+		if i >= column-1 {
+			break
+		}
+		if c == '\t' {
+			out.WriteRune('\t')
+			continue
+		}
+		out.WriteString(strings.Repeat(" ", width(c)))
+	}
+	return out.String()
+}
+
+// spanWidth returns how many cells the span of characters starting at the
+// column takes up on the screen.
+func spanWidth(line string, column int, span int) int {
+	runes := []rune(line)
+	total := 0
+	for i := column - 1; i < column-1+span && i < len(runes); i++ {
+		total += width(runes[i])
+	}
+	return max(total, span)
+}
+
+// wide are the East Asian wide and fullwidth characters, and emoji, which
+// terminals show two cells wide.
+var wide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1},
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe4f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x3fffd, Stride: 1},
+	},
 }
 
-func Warning(token token.Token, message string) {
-	fmt.Printf("[line %d] %s\n", token.Line, message)
+// width returns how many cells a character takes up on the screen.
+func width(c rune) int {
+	switch {
+	case unicode.In(c, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wide, c):
+		return 2
+	}
+	return 1
 }
@@ -3,38 +3,109 @@ package errors
 import (
 	"fmt"
 	"interp/token"
-	"strings"
+	"io"
+	"os"
 )
 
 type RuntimeError struct {
 	token   token.Token
 	message string
+	stack   []StackFrame
+}
+
+// StackFrame is a function that was running when a runtime error occurred,
+// and the token it had got to. File is the file the function is in; it is
+// empty for scripts that weren't given a file name.
+type StackFrame struct {
+	Function string
+	File     string
+	Token    token.Token
+}
+
+// Location returns where the frame had got to as file:line:column, or
+// line:column if it has no file.
+func (s StackFrame) Location() string {
+	location := fmt.Sprintf("%d:%d", s.Token.Line, s.Token.Column+1)
+	if s.File == "" {
+		return location
+	}
+	return s.File + ":" + location
+}
+
+func (s StackFrame) String() string {
+	return fmt.Sprintf("%s (%s)", s.Function, s.Location())
 }
 
 func NewRuntimeError(token token.Token, message string) RuntimeError {
 	return RuntimeError{token: token, message: message}
 }
 
+func (r RuntimeError) Token() token.Token {
+	return r.token
+}
+
+func (r RuntimeError) Message() string {
+	return r.message
+}
+
+// Stack returns the functions that were running when the error occurred,
+// innermost first. It is nil until the interpreter attaches it.
+func (r RuntimeError) Stack() []StackFrame {
+	return r.stack
+}
+
+func (r RuntimeError) WithStack(stack []StackFrame) RuntimeError {
+	r.stack = stack
+	return r
+}
+
 func (r RuntimeError) Error() string {
 	return fmt.Sprintf("[line %d] %s", r.token.Line, r.message)
 }
 
+// Print prints the error with a snippet of the source, followed by the stack
+// trace. Frames in the script of the outermost frame are shown with a line of
+// source; frames in other files are shown with a line read from the file.
 func (r RuntimeError) Print(source *string) {
-	line := r.token.Line - 1
-	lines := strings.Split(*source, "\n")
-
-	var newLines []string
+	r.Fprint(os.Stdout, *source)
+}
 
-	start := max(0, line-lineCount)
-	end := min(line+1+lineCount, len(lines))
-	spaces := strings.Repeat(" ", r.token.Column+2)
+func (r RuntimeError) Fprint(w io.Writer, source string) {
+	sources := map[string]string{}
+	if len(r.stack) > 0 {
+		sources[r.stack[len(r.stack)-1].File] = source
+	}
+	lookup := func(file string) (string, bool) {
+		if source, ok := sources[file]; ok {
+			return source, true
+		}
+		if file == "" {
+			return "", false
+		}
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return "", false
+		}
+		sources[file] = string(contents)
+		return sources[file], true
+	}
 
-	for i := start; i < end; i++ {
-		newLines = append(newLines, grey+fmt.Sprintf("%d", i+1)+none+" "+lines[i])
-		if i == line {
-			newLines = append(newLines, red+spaces+"^ Runtime error: "+r.message+none)
+	errorSource := source
+	if len(r.stack) > 0 {
+		if source, ok := lookup(r.stack[0].File); ok {
+			errorSource = source
 		}
 	}
+	printSnippet(w, errorSource, r.token.Line, r.token.Column+1, 1, red, "Runtime error: "+r.message)
 
-	fmt.Println(strings.Join(newLines, "\n"))
+	if len(r.stack) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(w, "Stack trace (innermost first):")
+	for _, frame := range r.stack {
+		_, _ = fmt.Fprintf(w, "  at %s\n", frame)
+		if source, ok := lookup(frame.File); ok {
+			printLine(w, source, frame.Token.Line, frame.Token.Column+1)
+		}
+	}
 }
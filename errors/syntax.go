@@ -3,6 +3,7 @@ package errors
 import (
 	"fmt"
 	"interp/token"
+	"os"
 	"strings"
 )
 
@@ -23,21 +24,16 @@ func (p SyntaxError) Error() string {
 }
 
 func (p SyntaxError) Print(source *string) {
-	line := p.token.Line - 1
-	lines := strings.Split(*source, "\n")
-
-	var newLines []string
+	printSnippet(os.Stdout, *source, p.token.Line, p.token.Column+1, 1, red, "Syntax error: "+p.message)
+}
 
-	start := max(0, line-lineCount)
-	end := min(line+1+lineCount, len(lines))
-	spaces := strings.Repeat(" ", p.token.Column+2)
+// SyntaxErrors is the list of syntax errors found while parsing a file.
+type SyntaxErrors []SyntaxError
 
-	for i := start; i < end; i++ {
-		newLines = append(newLines, grey+fmt.Sprintf("%d", i+1)+none+" "+lines[i])
-		if i == line {
-			newLines = append(newLines, red+spaces+"^ Syntax error: "+p.message+none)
-		}
+func (s SyntaxErrors) Error() string {
+	messages := make([]string, len(s))
+	for i, err := range s {
+		messages[i] = err.Error()
 	}
-
-	fmt.Println(strings.Join(newLines, "\n"))
+	return strings.Join(messages, "\n")
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"interp/errors"
+	"interp/formatter"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// formatScripts formats scripts in the canonical style. Directories are
+// searched for .g files. Without files, it formats stdin to stdout.
+func formatScripts(arguments []string) int {
+	flags := flag.NewFlagSet("interp fmt", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+	write := flags.Bool("w", false, "write the formatted scripts back to their files")
+	check := flags.Bool("check", false, "list the scripts that aren't formatted")
+	if err := flags.Parse(arguments); err != nil {
+		return exitUsage
+	}
+	if *write && *check {
+		flags.Usage()
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		bytes, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Println(err)
+			return exitIO
+		}
+		source := string(bytes)
+		formatted, status := formatScript(source, fileStdin)
+		switch {
+		case status != exitOK:
+			return status
+		case *check && formatted != source:
+			fmt.Println(fileStdin)
+			return exitFailure
+		case !*check:
+			fmt.Print(formatted)
+		}
+		return exitOK
+	}
+
+	files, err := scripts(flags.Args(), ".g")
+	if err != nil {
+		fmt.Println(err)
+		return exitIO
+	}
+
+	status := exitOK
+	for _, file := range files {
+		bytes, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Println(err)
+			status = exitIO
+			continue
+		}
+		source := string(bytes)
+
+		formatted, fileStatus := formatScript(source, file)
+		switch {
+		case fileStatus != exitOK:
+			status = fileStatus
+		case *check:
+			if formatted != source {
+				fmt.Println(file)
+				status = max(status, exitFailure)
+			}
+		case *write:
+			if formatted != source {
+				if err := os.WriteFile(file, []byte(formatted), 0o644); err != nil {
+					fmt.Println(err)
+					status = exitIO
+				}
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	return status
+}
+
+// formatScript formats a script and reports why it can't be.
+func formatScript(source string, file string) (string, int) {
+	diagnostics := errors.NewDiagnostics(file)
+	formatted, err := formatter.Format(source, diagnostics)
+	if diagnostics.HasErrors() {
+		report(diagnostics, source, formatPretty)
+		return "", exitSyntax
+	}
+	if err != nil {
+		fmt.Printf("%s: %v\n", file, err)
+		return "", exitFailure
+	}
+	return formatted, exitOK
+}
+
+// scripts returns the files given, and the files in the directories given
+// whose names end with the suffix.
+func scripts(paths []string, suffix string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.HasSuffix(path, suffix) {
+				files = append(files, path)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
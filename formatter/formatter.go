@@ -0,0 +1,106 @@
+// Package formatter prints scripts back in a canonical style: two spaces of
+// indentation, spaces around binary operators, opening braces on the line of
+// their statement and one statement per line. Comments are kept, next to
+// the statements they were next to, and single blank lines between
+// statements and comments too.
+package formatter
+
+import (
+	"fmt"
+	"interp/ast"
+	"interp/errors"
+	"interp/parser"
+	"interp/scanner"
+	"interp/token"
+	"reflect"
+)
+
+// Format formats a script. Syntax errors are reported to the diagnostics and
+// returned. As a safeguard, the formatted script is parsed again and must
+// give the same tree and keep every comment.
+func Format(source string, diagnostics *errors.Diagnostics) (string, error) {
+	statements, comments, count, err := parse(source, diagnostics)
+	if err != nil {
+		return "", err
+	}
+
+	formatted := printFile(statements, comments)
+
+	check := errors.NewDiagnostics("")
+	again, _, againCount, err := parse(formatted, check)
+	if err != nil {
+		return "", fmt.Errorf("the formatted script doesn't parse: %v", check.Items()[0])
+	}
+	if !equal(reflect.ValueOf(statements), reflect.ValueOf(again)) {
+		return "", fmt.Errorf("formatting changed the meaning of the script")
+	}
+	if againCount != count {
+		return "", fmt.Errorf("formatting lost comments")
+	}
+	return formatted, nil
+}
+
+// parse parses a script with its comments, and counts the comments.
+func parse(source string, diagnostics *errors.Diagnostics) ([]ast.Stmt, *ast.Comments, int, error) {
+	scan := scanner.NewScanner(source, diagnostics)
+	tokens, err := scan.ScanTokens()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	par := parser.NewParser(tokens, diagnostics)
+	par.SetComments(scan.Comments())
+	statements, err := par.Parse()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return statements, par.Comments(), len(scan.Comments()), nil
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// equal reports whether two trees are the same, wherever their nodes are in
+// the source.
+func equal(a reflect.Value, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	if a.Type() == tokenType {
+		return a.FieldByName("Type").String() == b.FieldByName("Type").String() &&
+			a.FieldByName("Lexeme").String() == b.FieldByName("Lexeme").String()
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equal(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package formatter
+
+import (
+	"interp/errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			"comments",
+			`// Leading.
+var x=1;   // Trailing.
+
+
+/* Between. */
+fun f() {
+  // Inside.
+  return;
+  // At the end.
+}
+// Last.
+`,
+			`// Leading.
+var x = 1; // Trailing.
+
+/* Between. */
+fun f() {
+  // Inside.
+  return;
+  // At the end.
+}
+// Last.
+`,
+		},
+		{
+			"blank lines between comments",
+			`fun f() {}
+// First group.
+// Still the first.
+
+
+/* Second
+   group. */
+
+// About g.
+fun g() {
+  return;
+  // End of g.
+
+  // More at the end.
+}
+
+// End of the file.
+`,
+			`fun f() {}
+// First group.
+// Still the first.
+
+/* Second
+   group. */
+
+// About g.
+fun g() {
+  return;
+  // End of g.
+
+  // More at the end.
+}
+
+// End of the file.
+`,
+		},
+		{
+			"else if",
+			`if(x>0){print "pos";}else if(x<0){print "neg";} else print "zero";`,
+			`if (x > 0) {
+  print "pos";
+} else if (x < 0) {
+  print "neg";
+} else print "zero";
+`,
+		},
+		{
+			"lambdas",
+			`var add=fun(a,b){return a+b;};
+call(fun(){});`,
+			`var add = fun (a, b) {
+  return a + b;
+};
+call(fun () {});
+`,
+		},
+		{
+			"collections",
+			`var m={"a":[1,2,[ ]],"b":{ }};
+m [ "a" ] [0]=-1;`,
+			`var m = {"a": [1, 2, []], "b": {}};
+m["a"][0] = -1;
+`,
+		},
+		{
+			"interpolation",
+			`print "sum ${f(1,2)} and ${ m["a"] }!";`,
+			`print "sum ${f(1, 2)} and ${m["a"]}!";
+`,
+		},
+		{
+			"raw strings",
+			"print `raw ${x} \\n`  ;\nprint \"\\t\\u{1F600}\";",
+			"print `raw ${x} \\n`;\nprint \"\\t\\u{1F600}\";\n",
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Format(test.source, errors.NewDiagnostics(""))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: formatted\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestIdempotent checks that the conformance scripts are formatted without
+// changing their meaning, and that formatting them again changes nothing.
+func TestIdempotent(t *testing.T) {
+	err := filepath.WalkDir("../testdata", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".g" {
+			return err
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		diagnostics := errors.NewDiagnostics(path)
+		formatted, err := Format(string(source), diagnostics)
+		if diagnostics.HasErrors() {
+			// Scripts that test syntax errors can't be formatted.
+			return nil
+		}
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+		again, err := Format(formatted, errors.NewDiagnostics(path))
+		if err != nil {
+			t.Errorf("%s: formatting again: %v", path, err)
+		} else if again != formatted {
+			t.Errorf("%s: formatting again changed\n%s\nto\n%s", path, formatted, again)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
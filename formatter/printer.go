@@ -0,0 +1,369 @@
+package formatter
+
+import (
+	"interp/ast"
+	"interp/token"
+	"strings"
+)
+
+const indentation = "  "
+
+type printer struct {
+	out      strings.Builder
+	comments *ast.Comments
+	indent   int
+	// lineStart is set when nothing has been written on the current line,
+	// which is indented once something is.
+	lineStart bool
+}
+
+// printFile prints a file.
+func printFile(statements []ast.Stmt, comments *ast.Comments) string {
+	p := &printer{comments: comments, lineStart: true}
+	p.statements(statements)
+	p.endComments(nil, len(statements))
+	return p.out.String()
+}
+
+func (p *printer) write(text string) {
+	if p.lineStart {
+		p.out.WriteString(strings.Repeat(indentation, p.indent))
+		p.lineStart = false
+	}
+	p.out.WriteString(text)
+}
+
+func (p *printer) newline() {
+	p.out.WriteString("\n")
+	p.lineStart = true
+}
+
+// comment writes a comment. Line comments lose their trailing spaces.
+func (p *printer) comment(comment token.Token) {
+	if strings.HasPrefix(comment.Lexeme, "//") {
+		p.write(strings.TrimRight(comment.Lexeme, " \t\r"))
+	} else {
+		p.write(comment.Lexeme)
+	}
+}
+
+// commentLines writes comments on lines of their own. A blank line is kept
+// wherever the source had blank lines between two of them, or between the
+// last of them and the next line, if it is given.
+func (p *printer) commentLines(comments []token.Token, next int) {
+	for i, comment := range comments {
+		if i > 0 && comment.Line > endLine(comments[i-1])+1 {
+			p.newline()
+		}
+		p.comment(comment)
+		p.newline()
+	}
+	if len(comments) > 0 && next > endLine(comments[len(comments)-1])+1 {
+		p.newline()
+	}
+}
+
+// endComments writes the comments at the end of the body of the owner, after
+// a blank line if the source had one after the last of its statements.
+func (p *printer) endComments(owner any, statements int) {
+	if statements > 0 && p.comments.EndSpaced[owner] {
+		p.newline()
+	}
+	p.commentLines(p.comments.End[owner], 0)
+}
+
+// endLine returns the line a comment ends on.
+func endLine(comment token.Token) int {
+	return comment.Line + strings.Count(comment.Lexeme, "\n")
+}
+
+// statements writes the statements of a body, each on its own lines.
+func (p *printer) statements(statements []ast.Stmt) {
+	for i, stmt := range statements {
+		if i > 0 && p.comments.Spaced[stmt] {
+			p.newline()
+		}
+		p.statement(stmt, func() { p.stmt(stmt) })
+	}
+}
+
+// statement writes a statement with its comments, using write to write the
+// statement itself.
+func (p *printer) statement(stmt ast.Stmt, write func()) {
+	p.commentLines(p.comments.Leading[stmt], ast.StmtStart(stmt).Line)
+	write()
+	for i, comment := range p.comments.Trailing[stmt] {
+		// Nothing can follow a line comment on its line.
+		if i > 0 && strings.HasPrefix(p.comments.Trailing[stmt][i-1].Lexeme, "//") {
+			p.newline()
+		} else {
+			p.write(" ")
+		}
+		p.comment(comment)
+	}
+	p.newline()
+}
+
+// body writes the statements of a body between braces, followed by the
+// comments at its end.
+func (p *printer) body(statements []ast.Stmt, owner any) {
+	end := p.comments.End[owner]
+	if len(statements) == 0 && len(end) == 0 {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.newline()
+	p.indent++
+	p.statements(statements)
+	p.endComments(owner, len(statements))
+	p.indent--
+	p.write("}")
+}
+
+func (p *printer) stmt(stmt ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStmt:
+		p.expr(stmt.Expression)
+		p.write(";")
+	case *ast.PrintStmt:
+		p.write("print ")
+		p.expr(stmt.Expression)
+		p.write(";")
+	case *ast.VarStmt:
+		p.varStmt(stmt)
+	case *ast.ReturnStmt:
+		p.write("return")
+		if stmt.Value != nil {
+			p.write(" ")
+			p.expr(stmt.Value)
+		}
+		p.write(";")
+	case *ast.BreakStmt:
+		p.write("break;")
+	case *ast.ContinueStmt:
+		p.write("continue;")
+	case *ast.ThrowStmt:
+		p.write("throw ")
+		p.expr(stmt.Value)
+		p.write(";")
+	case *ast.BlockStmt:
+		if stmt.Brace.Type == token.For {
+			// The block holds the initializer of a for loop and the loop.
+			p.forStmt(stmt.Statements[0], stmt.Statements[1].(*ast.WhileStmt))
+		} else {
+			p.body(stmt.Statements, stmt)
+		}
+	case *ast.WhileStmt:
+		if stmt.Keyword.Type == token.For {
+			p.forStmt(nil, stmt)
+		} else {
+			p.write("while (")
+			p.expr(stmt.Condition)
+			p.write(") ")
+			p.stmt(stmt.Body)
+		}
+	case *ast.IfStmt:
+		p.write("if (")
+		p.expr(stmt.Condition)
+		p.write(") ")
+		p.stmt(stmt.ThenBranch)
+		if stmt.ElseBranch != nil {
+			p.write(" else ")
+			p.stmt(stmt.ElseBranch)
+		}
+	case *ast.FunctionStmt:
+		p.write("fun ")
+		p.function(stmt)
+	case *ast.ClassStmt:
+		p.class(stmt)
+	case *ast.TryStmt:
+		p.write("try ")
+		p.body(stmt.Body.Statements, stmt.Body)
+		if stmt.Catch != nil {
+			p.write(" catch (" + stmt.Catch.Name.Lexeme + ") ")
+			p.body(stmt.Catch.Body, stmt.Catch)
+		}
+		if stmt.Finally != nil {
+			p.write(" finally ")
+			p.body(stmt.Finally.Statements, stmt.Finally)
+		}
+	case *ast.ImportStmt:
+		p.write("import ")
+		if stmt.Alias != nil {
+			p.write(stmt.Path.Lexeme + " as " + stmt.Alias.Lexeme + ";")
+		} else {
+			p.write("{ " + names(stmt.Names) + " } from " + stmt.Path.Lexeme + ";")
+		}
+	case *ast.ExportStmt:
+		p.write("export ")
+		p.stmt(stmt.Declaration)
+	}
+}
+
+func (p *printer) varStmt(stmt *ast.VarStmt) {
+	p.write("var " + stmt.Name.Lexeme)
+	if stmt.Initializer != nil {
+		p.write(" = ")
+		p.expr(stmt.Initializer)
+	}
+	p.write(";")
+}
+
+// forStmt writes a for loop, which the parser turned into a while loop and
+// maybe a block around it that runs the initializer first.
+func (p *printer) forStmt(initializer ast.Stmt, loop *ast.WhileStmt) {
+	p.write("for (")
+	switch initializer := initializer.(type) {
+	case nil:
+		p.write(";")
+	case *ast.VarStmt:
+		p.varStmt(initializer)
+	case *ast.ExpressionStmt:
+		p.expr(initializer.Expression)
+		p.write(";")
+	}
+
+	// A loop without a condition gets a true literal made from the 'for'.
+	if literal, ok := loop.Condition.(*ast.LiteralExpr); !ok || literal.Token.Type != token.For {
+		p.write(" ")
+		p.expr(loop.Condition)
+	}
+	p.write(";")
+
+	if loop.Increment != nil {
+		p.write(" ")
+		p.expr(loop.Increment)
+	}
+	p.write(") ")
+	p.stmt(loop.Body)
+}
+
+// function writes the name, parameters and body of a function or method.
+func (p *printer) function(function *ast.FunctionStmt) {
+	p.write(function.Name.Lexeme + "(" + names(function.Params) + ") ")
+	p.body(function.Body, function)
+}
+
+func (p *printer) class(class *ast.ClassStmt) {
+	p.write("class " + class.Name.Lexeme)
+	if class.Superclass != nil {
+		p.write(" < " + class.Superclass.Name.Lexeme)
+	}
+
+	end := p.comments.End[class]
+	if len(class.Methods) == 0 && len(end) == 0 {
+		p.write(" {}")
+		return
+	}
+
+	p.write(" {")
+	p.newline()
+	p.indent++
+	for i, method := range class.Methods {
+		if i > 0 && p.comments.Spaced[method] {
+			p.newline()
+		}
+		p.statement(method, func() { p.function(method) })
+	}
+	p.endComments(class, len(class.Methods))
+	p.indent--
+	p.write("}")
+}
+
+func (p *printer) expr(expr ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.AssignExpr:
+		p.write(expr.Name.Lexeme + " = ")
+		p.expr(expr.Value)
+	case *ast.BinaryExpr:
+		// The parts of an interpolated string are literals whose lexemes
+		// have the quotes and braces around the expressions.
+		if expr.Operator.Type == token.Interpolation {
+			p.expr(expr.Left)
+			p.expr(expr.Right)
+			break
+		}
+		p.expr(expr.Left)
+		p.write(" " + expr.Operator.Lexeme + " ")
+		p.expr(expr.Right)
+	case *ast.LogicalExpr:
+		p.expr(expr.Left)
+		p.write(" " + expr.Operator.Lexeme + " ")
+		p.expr(expr.Right)
+	case *ast.UnaryExpr:
+		p.write(expr.Operator.Lexeme)
+		p.expr(expr.Right)
+	case *ast.CallExpr:
+		p.expr(expr.Callee)
+		p.write("(")
+		p.exprs(expr.Arguments)
+		p.write(")")
+	case *ast.GetExpr:
+		p.expr(expr.Object)
+		p.write("." + expr.Name.Lexeme)
+	case *ast.SetExpr:
+		p.expr(expr.Object)
+		p.write("." + expr.Name.Lexeme + " = ")
+		p.expr(expr.Value)
+	case *ast.IndexGetExpr:
+		p.expr(expr.Object)
+		p.write("[")
+		p.expr(expr.Index)
+		p.write("]")
+	case *ast.IndexSetExpr:
+		p.expr(expr.Object)
+		p.write("[")
+		p.expr(expr.Index)
+		p.write("] = ")
+		p.expr(expr.Value)
+	case *ast.GroupingExpr:
+		p.write("(")
+		p.expr(expr.Expression)
+		p.write(")")
+	case *ast.LiteralExpr:
+		p.write(expr.Token.Lexeme)
+	case *ast.VariableExpr:
+		p.write(expr.Name.Lexeme)
+	case *ast.ThisExpr:
+		p.write("this")
+	case *ast.SuperExpr:
+		p.write("super." + expr.Method.Lexeme)
+	case *ast.LambdaExpr:
+		p.write("fun (" + names(expr.Params) + ") ")
+		p.body(expr.Body, expr)
+	case *ast.ListExpr:
+		p.write("[")
+		p.exprs(expr.Elements)
+		p.write("]")
+	case *ast.MapExpr:
+		p.write("{")
+		for i := range expr.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expr(expr.Keys[i])
+			p.write(": ")
+			p.expr(expr.Values[i])
+		}
+		p.write("}")
+	}
+}
+
+func (p *printer) exprs(exprs []ast.Expr) {
+	for i, expr := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expr(expr)
+	}
+}
+
+func names(tokens []token.Token) string {
+	lexemes := make([]string, len(tokens))
+	for i, token := range tokens {
+		lexemes[i] = token.Lexeme
+	}
+	return strings.Join(lexemes, ", ")
+}
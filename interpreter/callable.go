@@ -1,6 +1,8 @@
 package interpreter
 
+// Callable is a value that can be called from a script. Natives defined
+// outside this package implement it too.
 type Callable interface {
-	call(interpreter *Interpreter, arguments []any) (any, error)
-	arity() int
+	Call(interpreter *Interpreter, arguments []any) (any, error)
+	Arity() int
 }
@@ -1,12 +1,13 @@
 package interpreter
 
 type Class struct {
-	Name    string
-	methods map[string]*Function
+	Name       string
+	superclass *Class
+	methods    map[string]*Function
 }
 
-func NewClass(name string, methods map[string]*Function) *Class {
-	return &Class{name, methods}
+func NewClass(name string, superclass *Class, methods map[string]*Function) *Class {
+	return &Class{name, superclass, methods}
 }
 
 func (c *Class) String() string {
@@ -14,14 +15,20 @@ func (c *Class) String() string {
 }
 
 func (c *Class) findMethod(name string) *Function {
-	return c.methods[name]
+	if method, ok := c.methods[name]; ok {
+		return method
+	}
+	if c.superclass != nil {
+		return c.superclass.findMethod(name)
+	}
+	return nil
 }
 
-func (c *Class) call(interpreter *Interpreter, arguments []any) (any, error) {
+func (c *Class) Call(interpreter *Interpreter, arguments []any) (any, error) {
 	instance := NewInstance(c)
 	initializer := c.findMethod("init")
 	if initializer != nil {
-		_, err := initializer.bind(instance).call(interpreter, arguments)
+		_, err := initializer.bind(instance).Call(interpreter, arguments)
 		if err != nil {
 			return nil, err
 		}
@@ -30,10 +37,10 @@ func (c *Class) call(interpreter *Interpreter, arguments []any) (any, error) {
 	return instance, nil
 }
 
-func (c *Class) arity() int {
+func (c *Class) Arity() int {
 	initializer := c.findMethod("init")
 	if initializer == nil {
 		return 0
 	}
-	return initializer.arity()
+	return initializer.Arity()
 }
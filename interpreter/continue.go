@@ -0,0 +1,7 @@
+package interpreter
+
+type Continue struct{}
+
+func (c Continue) Error() string {
+	return "continue"
+}
@@ -0,0 +1,62 @@
+package interpreter
+
+import (
+	"interp/ast"
+	"interp/environment"
+	"interp/errors"
+	"interp/token"
+)
+
+// Debugger is told about every statement before it is executed, and can
+// inspect the interpreter while it is paused there.
+type Debugger interface {
+	// Pause is called before the statement is executed. An error it returns
+	// stops the script; it can't be caught by the script.
+	Pause(stmt ast.Stmt) error
+}
+
+func (i *Interpreter) SetDebugger(debugger Debugger) {
+	i.debugger = debugger
+}
+
+// Depth returns the number of calls of script functions in progress.
+func (i *Interpreter) Depth() int {
+	return len(i.frames)
+}
+
+// File returns the name of the file the code being run is in.
+func (i *Interpreter) File() string {
+	return i.file
+}
+
+// Environment returns the environment of the code being run.
+func (i *Interpreter) Environment() *environment.Environment {
+	return i.environment
+}
+
+// Environments returns the environments of the calls in progress, in the
+// same order as Stack: the current one first, then those of the callers.
+func (i *Interpreter) Environments() []*environment.Environment {
+	environments := []*environment.Environment{i.environment}
+	for index := len(i.frames) - 1; index >= 0; index-- {
+		environments = append(environments, i.frames[index].environment)
+	}
+	return environments
+}
+
+// Stack returns the calls in progress, innermost first, given the token the
+// innermost one has got to.
+func (i *Interpreter) Stack(at token.Token) []errors.StackFrame {
+	return i.stackTrace(at)
+}
+
+// Evaluate evaluates an expression in the environment. Its variables must
+// have been resolved against that environment.
+func (i *Interpreter) Evaluate(expr ast.Expr, env *environment.Environment) (any, error) {
+	previous := i.environment
+	i.environment = env
+	defer func() {
+		i.environment = previous
+	}()
+	return i.evaluate(expr)
+}
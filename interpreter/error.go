@@ -0,0 +1,53 @@
+package interpreter
+
+import (
+	"fmt"
+	"interp/errors"
+	"interp/token"
+)
+
+// Error is the value a runtime error is caught as. Scripts can read its
+// message, line and stack, and throw it again.
+type Error struct {
+	err errors.RuntimeError
+}
+
+func NewError(err errors.RuntimeError) *Error {
+	return &Error{err}
+}
+
+// RuntimeError returns the runtime error that was caught.
+func (e *Error) RuntimeError() errors.RuntimeError {
+	return e.err
+}
+
+func (e *Error) Property(name token.Token) (any, error) {
+	switch name.Lexeme {
+	case "message":
+		return e.err.Message(), nil
+	case "line":
+		return int64(e.err.Token().Line), nil
+	case "stack":
+		stack := make([]any, len(e.err.Stack()))
+		for index, frame := range e.err.Stack() {
+			stack[index] = frame.String()
+		}
+		return NewList(stack), nil
+	}
+	return nil, errors.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+}
+
+func (e *Error) String() string {
+	return "<error: " + e.err.Message() + ">"
+}
+
+// Uncaught returns the runtime error that ends a script when nothing caught
+// the thrown value. A caught runtime error that is thrown again is reported
+// as it was originally.
+func (i *Interpreter) Uncaught(value any, keyword token.Token, stack []errors.StackFrame) errors.RuntimeError {
+	if err, ok := value.(*Error); ok {
+		return err.err
+	}
+	message := fmt.Sprintf("Uncaught exception: %s", i.stringify(value))
+	return errors.NewRuntimeError(keyword, message).WithStack(stack)
+}
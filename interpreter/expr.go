@@ -19,75 +19,32 @@ func (i *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) (any, error) {
 	}
 
 	switch expr.Operator.Type {
-	case token.Greater:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+	case token.Greater, token.GreaterEqual, token.Less, token.LessEqual:
+		result, err := Comparison(expr.Operator.Type, left, right)
 		if err != nil {
-			return nil, err
-		}
-
-		return left.(float64) > right.(float64), nil
-	case token.GreaterEqual:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-
-		return left.(float64) >= right.(float64), nil
-	case token.Less:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-
-		return left.(float64) < right.(float64), nil
-	case token.LessEqual:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+			return nil, errors.NewRuntimeError(expr.Operator, err.Error())
 		}
-
-		return left.(float64) <= right.(float64), nil
+		return result, nil
 	case token.BangEqual:
-		return left != right, nil
+		return !Equal(left, right), nil
 	case token.EqualEqual:
-		return left == right, nil
-	case token.Minus:
-		err := i.checkNumberOperand(expr.Operator, right)
-		if err != nil {
-			return nil, err
-		}
-
-		return left.(float64) - right.(float64), nil
+		return Equal(left, right), nil
+	case token.Interpolation:
+		return i.stringify(left) + i.stringify(right), nil
 	case token.Plus:
-		if i.isFloat(left) && i.isFloat(right) {
-			return left.(float64) + right.(float64), nil
-		}
 		if i.isString(left) && i.isString(right) {
 			return left.(string) + right.(string), nil
 		}
-
-		return nil, errors.NewRuntimeError(expr.Operator, "Operands must be two numbers or two strings.")
-	case token.Slash:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-
-		if right.(float64) == 0 {
-			return nil, errors.NewRuntimeError(expr.Operator, "Can not divide by zero.")
-		}
-
-		return left.(float64) / right.(float64), nil
-	case token.Star:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+		if !IsNumber(left) || !IsNumber(right) {
+			return nil, errors.NewRuntimeError(expr.Operator, "Operands must be two numbers or two strings.")
 		}
-
-		return left.(float64) * right.(float64), nil
 	}
 
-	return nil, nil
+	result, err := Arithmetic(expr.Operator.Type, left, right)
+	if err != nil {
+		return nil, errors.NewRuntimeError(expr.Operator, err.Error())
+	}
+	return result, nil
 }
 
 func (i *Interpreter) VisitCallExpr(expr *ast.CallExpr) (any, error) {
@@ -110,14 +67,62 @@ func (i *Interpreter) VisitCallExpr(expr *ast.CallExpr) (any, error) {
 		return nil, errors.NewRuntimeError(expr.Paren, "Can only call functions and classes.")
 	}
 
-	if len(arguments) != function.arity() {
+	if len(arguments) != function.Arity() {
 		return nil, errors.NewRuntimeError(
 			expr.Paren,
-			fmt.Sprintf("Expected %d arguments but got %d.", function.arity(), len(arguments)),
+			fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), len(arguments)),
 		)
 	}
 
-	return function.call(i, arguments)
+	value, err := i.call(function, arguments, expr.Paren)
+	switch err := err.(type) {
+	case NativeError:
+		return nil, errors.NewRuntimeError(expr.Paren, err.message)
+	case CallSiteError:
+		return nil, err.At(expr.Paren)
+	}
+	return value, err
+}
+
+// call calls the callee from the call at paren. Calls of script functions
+// are on the call stack while they run, and run in the file the function is
+// in.
+func (i *Interpreter) call(callee Callable, arguments []any, paren token.Token) (any, error) {
+	name, file, traced := i.frameOf(callee)
+	if !traced {
+		return callee.Call(i, arguments)
+	}
+
+	if len(i.frames)+1 == MaxFrames {
+		return nil, errors.NewRuntimeError(paren, "Stack overflow.")
+	}
+	i.frames = append(i.frames, frame{name, paren, i.file, i.environment})
+	i.file = file
+	value, err := callee.Call(i, arguments)
+	if err != nil {
+		err = i.withStack(err)
+	}
+	i.file = i.frames[len(i.frames)-1].file
+	i.frames = i.frames[:len(i.frames)-1]
+	return value, err
+}
+
+// frameOf returns the name a call shows up as in stack traces, and the file
+// the callee is in. Natives don't show up, their errors are reported where
+// they were called.
+func (i *Interpreter) frameOf(callee Callable) (string, string, bool) {
+	switch callee := callee.(type) {
+	case *Function:
+		return callee.declaration.Name.Lexeme, callee.file, true
+	case Lambda:
+		return "<lambda>", callee.file, true
+	case *Class:
+		if initializer := callee.findMethod("init"); initializer != nil {
+			return "init", initializer.file, true
+		}
+		return "init", i.file, true
+	}
+	return "", "", false
 }
 
 func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) (any, error) {
@@ -125,19 +130,87 @@ func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	if _, ok := object.(*Instance); ok {
-		return object.(*Instance).get(expr.Name)
+	switch object := object.(type) {
+	case *Instance:
+		return object.get(expr.Name)
+	case *List:
+		return object.Property(expr.Name)
+	case *Map:
+		return object.Property(expr.Name)
+	case *Error:
+		return object.Property(expr.Name)
+	case *Module:
+		return object.Property(expr.Name)
 	}
 
 	return nil, errors.NewRuntimeError(expr.Name, "Only instances have properties.")
 }
 
+func (i *Interpreter) VisitIndexGetExpr(expr *ast.IndexGetExpr) (any, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *List:
+		return object.GetIndex(expr.Bracket, index)
+	case *Map:
+		return object.GetIndex(expr.Bracket, index)
+	}
+
+	return nil, errors.NewRuntimeError(expr.Bracket, "Only lists and maps can be indexed.")
+}
+
+func (i *Interpreter) VisitIndexSetExpr(expr *ast.IndexSetExpr) (any, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *List:
+		return value, object.SetIndex(expr.Bracket, index, value)
+	case *Map:
+		return value, object.SetIndex(expr.Bracket, index, value)
+	}
+
+	return nil, errors.NewRuntimeError(expr.Bracket, "Only lists and maps can be indexed.")
+}
+
 func (i *Interpreter) VisitGroupingExpr(expr *ast.GroupingExpr) (any, error) {
 	return i.evaluate(expr.Expression)
 }
 
 func (i *Interpreter) VisitLambdaExpr(expr *ast.LambdaExpr) (any, error) {
-	return NewLambda(expr, i.environment), nil
+	return NewLambda(expr, i.environment, i.file), nil
+}
+
+func (i *Interpreter) VisitListExpr(expr *ast.ListExpr) (any, error) {
+	elements := make([]any, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return NewList(elements), nil
 }
 
 func (i *Interpreter) VisitLiteralExpr(expr *ast.LiteralExpr) (any, error) {
@@ -163,6 +236,25 @@ func (i *Interpreter) VisitLogicalExpr(expr *ast.LogicalExpr) (any, error) {
 	return i.evaluate(expr.Right)
 }
 
+func (i *Interpreter) VisitMapExpr(expr *ast.MapExpr) (any, error) {
+	result := NewMap()
+	for index, keyExpr := range expr.Keys {
+		key, err := i.evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.evaluate(expr.Values[index])
+		if err != nil {
+			return nil, err
+		}
+		err = result.SetIndex(expr.Brace, key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
 func (i *Interpreter) VisitSetExpr(expr *ast.SetExpr) (any, error) {
 	object, err := i.evaluate(expr.Object)
 	if err != nil {
@@ -182,6 +274,20 @@ func (i *Interpreter) VisitSetExpr(expr *ast.SetExpr) (any, error) {
 	return value, nil
 }
 
+func (i *Interpreter) VisitSuperExpr(expr *ast.SuperExpr) (any, error) {
+	// "super" and "this" are the only variables in their scopes.
+	distance := i.locals[expr].depth
+	superclass := i.environment.GetAt(distance, 0).(*Class)
+	object := i.environment.GetAt(distance-1, 0).(*Instance)
+
+	method := superclass.findMethod(expr.Method.Lexeme)
+	if method == nil {
+		return nil, errors.NewRuntimeError(expr.Method, fmt.Sprintf("Undefined property '%s'.", expr.Method.Lexeme))
+	}
+
+	return method.bind(object), nil
+}
+
 func (i *Interpreter) VisitThisExpr(expr *ast.ThisExpr) (any, error) {
 	return i.lookUpVariable(expr.Keyword, expr)
 }
@@ -196,7 +302,11 @@ func (i *Interpreter) VisitUnaryExpr(expr *ast.UnaryExpr) (any, error) {
 	case token.Bang:
 		return !i.isTruthy(right), nil
 	case token.Minus:
-		return -right.(float64), nil
+		negated, ok := Negate(right)
+		if !ok {
+			return nil, errors.NewRuntimeError(expr.Operator, "Operand must be a number.")
+		}
+		return negated, nil
 	}
 
 	return nil, nil
@@ -207,11 +317,11 @@ func (i *Interpreter) VisitVariableExpr(expr *ast.VariableExpr) (any, error) {
 }
 
 func (i *Interpreter) lookUpVariable(name token.Token, expr ast.Expr) (any, error) {
-	distance, found := i.locals[expr]
+	local, found := i.locals[expr]
 	if found {
-		return i.environment.GetAt(distance, name.Lexeme), nil
+		return i.environment.GetAt(local.depth, local.slot), nil
 	} else {
-		return i.globals.Get(name)
+		return i.environment.Get(name)
 	}
 }
 
@@ -221,11 +331,11 @@ func (i *Interpreter) VisitAssignExpr(expr *ast.AssignExpr) (any, error) {
 		return nil, err
 	}
 
-	distance, found := i.locals[expr]
+	local, found := i.locals[expr]
 	if found {
-		i.environment.AssignAt(distance, expr.Name, value)
+		i.environment.AssignAt(local.depth, local.slot, value)
 	} else {
-		err = i.globals.Assign(expr.Name, value)
+		err = i.environment.Assign(expr.Name, value)
 		if err != nil {
 			return nil, err
 		}
@@ -10,20 +10,22 @@ type Function struct {
 	declaration   *ast.FunctionStmt
 	closure       *environment.Environment
 	isInitializer bool
+	// file is the file the function is declared in, for stack traces.
+	file string
 }
 
-func NewFunction(declaration *ast.FunctionStmt, closure *environment.Environment, isInitializer bool) *Function {
-	return &Function{declaration, closure, isInitializer}
+func NewFunction(declaration *ast.FunctionStmt, closure *environment.Environment, isInitializer bool, file string) *Function {
+	return &Function{declaration, closure, isInitializer, file}
 }
 
 func (f *Function) bind(instance *Instance) *Function {
 	env := environment.NewEnvironment(f.closure)
 	env.Define("this", instance)
-	return NewFunction(f.declaration, env, f.isInitializer)
+	return NewFunction(f.declaration, env, f.isInitializer, f.file)
 }
 
 //goland:noinspection GoTypeAssertionOnErrors
-func (f *Function) call(interpreter *Interpreter, arguments []any) (any, error) {
+func (f *Function) Call(interpreter *Interpreter, arguments []any) (any, error) {
 	env := environment.NewEnvironment(f.closure)
 	for i, param := range f.declaration.Params {
 		env.Define(param.Lexeme, arguments[i])
@@ -36,19 +38,19 @@ func (f *Function) call(interpreter *Interpreter, arguments []any) (any, error)
 			return nil, err
 		}
 		if f.isInitializer {
-			return f.closure.GetAt(0, "this"), nil
+			return f.closure.GetAt(0, 0), nil
 		}
 		return returnValue.Value, nil
 	}
 
 	if f.isInitializer {
-		return f.closure.GetAt(0, "this"), nil
+		return f.closure.GetAt(0, 0), nil
 	}
 
 	return nil, nil
 }
 
-func (f *Function) arity() int {
+func (f *Function) Arity() int {
 	return len(f.declaration.Params)
 }
 
@@ -8,7 +8,7 @@ func NewClock() Callable {
 	return Clock{}
 }
 
-func (c Clock) arity() int {
+func (c Clock) Arity() int {
 	return 0
 }
 
@@ -16,6 +16,6 @@ func (c Clock) String() string {
 	return "<native fn>"
 }
 
-func (c Clock) call(interpreter *Interpreter, arguments []any) (any, error) {
+func (c Clock) Call(interpreter *Interpreter, arguments []any) (any, error) {
 	return float64(time.Now().UnixMilli()) / 1000, nil
 }
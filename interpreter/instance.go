@@ -22,6 +22,11 @@ func (i *Instance) String() string {
 	return i.class.Name + " instance"
 }
 
+// Fields returns the fields of the instance. The map must not be modified.
+func (i *Instance) Fields() map[string]any {
+	return i.fields
+}
+
 func (i *Instance) get(name token.Token) (any, error) {
 	if field, exists := i.fields[name.Lexeme]; exists {
 		return field, nil
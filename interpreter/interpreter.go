@@ -1,48 +1,202 @@
 package interpreter
 
 import (
+	"bufio"
 	"interp/ast"
 	"interp/environment"
+	"interp/errors"
+	"interp/token"
+	"io"
+	"math/rand"
+	"os"
+	"time"
 )
 
 type Interpreter struct {
 	environment *environment.Environment
 	globals     *environment.Environment
-	locals      map[ast.Expr]int
+	locals      map[ast.Expr]local
+	stdin       *bufio.Reader
+	stdout      io.Writer
+	random      *rand.Rand
+	frames      []frame
+	file        string
+	modules     *Modules
+	debugger    Debugger
 }
 
+// frame is a call of a script function that is in progress.
+type frame struct {
+	function string
+	call     token.Token
+	// file and environment are those of the code that made the call.
+	file        string
+	environment *environment.Environment
+}
+
+// MaxFrames is how deep calls can nest, counting the top level of the
+// script, before the stack overflows. The bytecode VM has the same limit.
+const MaxFrames = 1 << 16
+
 func NewInterpreter() Interpreter {
 	globals := environment.NewEnvironment(nil)
 
-	globals.Define("clock", NewClock())
+	for name, builtin := range Builtins() {
+		globals.Define(name, builtin)
+	}
 
 	return Interpreter{
 		globals:     globals,
 		environment: globals,
-		locals:      map[ast.Expr]int{},
+		locals:      map[ast.Expr]local{},
+		stdin:       bufio.NewReader(os.Stdin),
+		stdout:      os.Stdout,
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		modules:     NewModules(),
+	}
+}
+
+// SetInput sets the reader used by natives that read input.
+func (i *Interpreter) SetInput(reader io.Reader) {
+	i.stdin = bufio.NewReader(reader)
+}
+
+// SetOutput sets the writer that print statements write to.
+func (i *Interpreter) SetOutput(writer io.Writer) {
+	i.stdout = writer
+}
+
+// SetFile sets the name of the script being run, which stack traces show and
+// imports are relative to. Scripts that aren't files, whose names are like
+// "<stdin>", import relative to the working directory.
+func (i *Interpreter) SetFile(file string) {
+	i.file = file
+	i.modules.SetEntry(file)
+}
+
+// SetSearchPath sets the directories that imported modules are looked for in
+// when they aren't found relative to the importing script.
+func (i *Interpreter) SetSearchPath(directories []string) {
+	i.modules.SetSearchPath(directories)
+}
+
+func (i *Interpreter) Define(name string, value any) {
+	i.globals.Define(name, value)
+}
+
+// Global returns the value of a global variable.
+func (i *Interpreter) Global(name string) (any, bool) {
+	return i.globals.Lookup(name)
+}
+
+// Stringify returns the text that a print statement shows for the value.
+func (i *Interpreter) Stringify(value any) string {
+	return i.stringify(value)
+}
+
+// Truthy reports whether the value counts as true in a condition.
+func (i *Interpreter) Truthy(value any) bool {
+	return i.isTruthy(value)
+}
+
+// Call calls a callable value with the given arguments. Errors returned by
+// natives are returned as NativeError.
+func (i *Interpreter) Call(callee Callable, arguments []any) (any, error) {
+	if len(arguments) != callee.Arity() {
+		return nil, NewNativeError("Expected %d arguments but got %d.", callee.Arity(), len(arguments))
 	}
+	value, err := i.call(callee, arguments, token.Token{})
+	return value, i.uncaught(err)
+}
+
+// Try calls a callable like Call, and catches what it throws as a catch
+// clause would: thrown values as they are and runtime errors as Error
+// values. Errors that can't be caught are returned.
+func (i *Interpreter) Try(callee Callable, arguments []any) (caught any, threw bool, err error) {
+	if len(arguments) != callee.Arity() {
+		return nil, false, NewNativeError("Expected %d arguments but got %d.", callee.Arity(), len(arguments))
+	}
+	_, err = i.call(callee, arguments, token.Token{})
+	if err == nil {
+		return nil, false, nil
+	}
+	if caught, ok := Caught(i.withStack(err)); ok {
+		return caught, true, nil
+	}
+	return nil, false, err
 }
 
 func (i *Interpreter) Interpret(statements []ast.Stmt) error {
 	for _, statement := range statements {
 		_, err := i.execute(statement)
 		if err != nil {
-			return err
+			return i.uncaught(err)
 		}
 	}
 	return nil
 }
 
+// uncaught turns errors that reached the top without being caught into the
+// runtime errors that are reported.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func (i *Interpreter) uncaught(err error) error {
+	if throw, ok := err.(Throw); ok {
+		return i.Uncaught(throw.Value, throw.Keyword, throw.stack)
+	}
+	return i.withStack(err)
+}
+
+// withStack attaches the current call stack to a runtime error that doesn't
+// have one yet.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func (i *Interpreter) withStack(err error) error {
+	if err, ok := err.(errors.RuntimeError); ok && err.Stack() == nil {
+		return err.WithStack(i.stackTrace(err.Token()))
+	}
+	return err
+}
+
+// stackTrace returns the calls in progress, innermost first, given the token
+// the innermost one has got to.
+func (i *Interpreter) stackTrace(at token.Token) []errors.StackFrame {
+	stack := make([]errors.StackFrame, 0, len(i.frames)+1)
+	file := i.file
+	for index := len(i.frames) - 1; index >= 0; index-- {
+		stack = append(stack, errors.StackFrame{Function: i.frames[index].function, File: file, Token: at})
+		at = i.frames[index].call
+		file = i.frames[index].file
+	}
+	// Functions called by Call have no call site in a script.
+	if at.Line == 0 {
+		return stack
+	}
+	return append(stack, errors.StackFrame{Function: "script", File: file, Token: at})
+}
+
 func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 	return expr.Accept(i)
 }
 
 func (i *Interpreter) execute(stmt ast.Stmt) (any, error) {
+	if i.debugger != nil {
+		if err := i.debugger.Pause(stmt); err != nil {
+			return nil, err
+		}
+	}
 	return stmt.Accept(i)
 }
 
-func (i *Interpreter) Resolve(expr ast.Expr, depth int) {
-	i.locals[expr] = depth
+// local is where a local variable lives: how many environments up from the
+// current one, and at which slot.
+type local struct {
+	depth int
+	slot  int
+}
+
+func (i *Interpreter) Resolve(expr ast.Expr, depth int, slot int) {
+	i.locals[expr] = local{depth, slot}
 }
 
 func (i *Interpreter) executeBlock(statements []ast.Stmt, environment *environment.Environment) error {
@@ -8,13 +8,15 @@ import (
 type Lambda struct {
 	expression *ast.LambdaExpr
 	closure    *environment.Environment
+	// file is the file the lambda is in, for stack traces.
+	file string
 }
 
-func NewLambda(expression *ast.LambdaExpr, closure *environment.Environment) Callable {
-	return Lambda{expression: expression, closure: closure}
+func NewLambda(expression *ast.LambdaExpr, closure *environment.Environment, file string) Callable {
+	return Lambda{expression: expression, closure: closure, file: file}
 }
 
-func (l Lambda) call(interpreter *Interpreter, arguments []any) (any, error) {
+func (l Lambda) Call(interpreter *Interpreter, arguments []any) (any, error) {
 	env := environment.NewEnvironment(l.closure)
 	for i, param := range l.expression.Params {
 		env.Define(param.Lexeme, arguments[i])
@@ -31,6 +33,10 @@ func (l Lambda) call(interpreter *Interpreter, arguments []any) (any, error) {
 	return nil, nil
 }
 
-func (l Lambda) arity() int {
+func (l Lambda) Arity() int {
 	return len(l.expression.Params)
 }
+
+func (l Lambda) String() string {
+	return "<lambda>"
+}
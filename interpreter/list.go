@@ -0,0 +1,154 @@
+package interpreter
+
+import (
+	"fmt"
+	"interp/errors"
+	"interp/token"
+	"math"
+)
+
+type List struct {
+	elements []any
+}
+
+func NewList(elements []any) *List {
+	return &List{elements}
+}
+
+func (l *List) Elements() []any {
+	return l.elements
+}
+
+// Property returns the method of the list with the given name.
+func (l *List) Property(name token.Token) (any, error) {
+	switch name.Lexeme {
+	case "push":
+		return NewNative("push", 1, func(_ *Interpreter, arguments []any) (any, error) {
+			l.elements = append(l.elements, arguments[0])
+			return nil, nil
+		}), nil
+	case "pop":
+		return NewNative("pop", 0, func(_ *Interpreter, _ []any) (any, error) {
+			if len(l.elements) == 0 {
+				return nil, NewNativeError("Can't pop from an empty list.")
+			}
+			value := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return value, nil
+		}), nil
+	case "len":
+		return NewNative("len", 0, func(_ *Interpreter, _ []any) (any, error) {
+			return int64(len(l.elements)), nil
+		}), nil
+	case "insert":
+		return NewNative("insert", 2, func(_ *Interpreter, arguments []any) (any, error) {
+			index, err := listIndex(arguments[0], len(l.elements)+1)
+			if err != nil {
+				return nil, NewNativeError(err.Error())
+			}
+			l.elements = append(l.elements, nil)
+			copy(l.elements[index+1:], l.elements[index:])
+			l.elements[index] = arguments[1]
+			return nil, nil
+		}), nil
+	case "remove":
+		return NewNative("remove", 1, func(_ *Interpreter, arguments []any) (any, error) {
+			index, err := listIndex(arguments[0], len(l.elements))
+			if err != nil {
+				return nil, NewNativeError(err.Error())
+			}
+			value := l.elements[index]
+			l.elements = append(l.elements[:index], l.elements[index+1:]...)
+			return value, nil
+		}), nil
+	case "slice":
+		return NewNative("slice", 2, func(_ *Interpreter, arguments []any) (any, error) {
+			start, err := sliceBound(arguments[0], len(l.elements))
+			if err != nil {
+				return nil, NewNativeError(err.Error())
+			}
+			end, err := sliceBound(arguments[1], len(l.elements))
+			if err != nil {
+				return nil, NewNativeError(err.Error())
+			}
+			elements := make([]any, 0, max(0, end-start))
+			if start < end {
+				elements = append(elements, l.elements[start:end]...)
+			}
+			return NewList(elements), nil
+		}), nil
+	case "contains":
+		return NewNative("contains", 1, func(_ *Interpreter, arguments []any) (any, error) {
+			for _, element := range l.elements {
+				if Equal(element, arguments[0]) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}), nil
+	}
+
+	return nil, errors.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+}
+
+// GetIndex returns the element at the index. Errors are reported at the
+// bracket token.
+func (l *List) GetIndex(bracket token.Token, index any) (any, error) {
+	i, err := listIndex(index, len(l.elements))
+	if err != nil {
+		return nil, errors.NewRuntimeError(bracket, err.Error())
+	}
+	return l.elements[i], nil
+}
+
+// SetIndex sets the element at the index. Errors are reported at the bracket
+// token.
+func (l *List) SetIndex(bracket token.Token, index any, value any) error {
+	i, err := listIndex(index, len(l.elements))
+	if err != nil {
+		return errors.NewRuntimeError(bracket, err.Error())
+	}
+	l.elements[i] = value
+	return nil
+}
+
+// listIndex converts a script value into an index into a list of the given
+// length. Negative indexes count from the end of the list.
+func listIndex(value any, length int) (int, error) {
+	index, err := integer(value)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 {
+		index += length
+	}
+	if index < 0 || index >= length {
+		return 0, NewNativeError("Index out of range.")
+	}
+	return index, nil
+}
+
+// sliceBound is like listIndex, but clamps the result to [0, length].
+func sliceBound(value any, length int) (int, error) {
+	index, err := integer(value)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 {
+		index += length
+	}
+	return min(max(index, 0), length), nil
+}
+
+func integer(value any) (int, error) {
+	switch number := value.(type) {
+	case int64:
+		return int(number), nil
+	case float64:
+		if number != math.Trunc(number) {
+			return 0, NewNativeError("Index must be an integer.")
+		}
+		return int(number), nil
+	}
+	return 0, NewNativeError("Index must be a number.")
+}
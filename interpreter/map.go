@@ -0,0 +1,144 @@
+package interpreter
+
+import (
+	"fmt"
+	"interp/errors"
+	"interp/token"
+	"math"
+)
+
+// Map is a hash map that remembers the order in which keys were inserted, so
+// that iterating over it is deterministic.
+type Map struct {
+	keys   []any
+	values map[any]any
+}
+
+func NewMap() *Map {
+	return &Map{values: map[any]any{}}
+}
+
+// Keys returns the keys of the map in insertion order.
+func (m *Map) Keys() []any {
+	return m.keys
+}
+
+func (m *Map) Get(key any) (any, bool) {
+	value, ok := m.values[mapKey(key)]
+	return value, ok
+}
+
+// Set sets the value of a key. It returns an error if the key can't be used
+// as a map key.
+func (m *Map) Set(key any, value any) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	m.set(key, value)
+	return nil
+}
+
+// Property returns the method of the map with the given name.
+func (m *Map) Property(name token.Token) (any, error) {
+	switch name.Lexeme {
+	case "keys":
+		return NewNative("keys", 0, func(_ *Interpreter, _ []any) (any, error) {
+			keys := make([]any, len(m.keys))
+			copy(keys, m.keys)
+			return NewList(keys), nil
+		}), nil
+	case "values":
+		return NewNative("values", 0, func(_ *Interpreter, _ []any) (any, error) {
+			values := make([]any, len(m.keys))
+			for index, key := range m.keys {
+				values[index] = m.values[key]
+			}
+			return NewList(values), nil
+		}), nil
+	case "has":
+		return NewNative("has", 1, func(_ *Interpreter, arguments []any) (any, error) {
+			if err := checkKey(arguments[0]); err != nil {
+				return nil, err
+			}
+			_, ok := m.values[mapKey(arguments[0])]
+			return ok, nil
+		}), nil
+	case "delete":
+		return NewNative("delete", 1, func(_ *Interpreter, arguments []any) (any, error) {
+			if err := checkKey(arguments[0]); err != nil {
+				return nil, err
+			}
+			return m.delete(arguments[0]), nil
+		}), nil
+	case "len":
+		return NewNative("len", 0, func(_ *Interpreter, _ []any) (any, error) {
+			return int64(len(m.keys)), nil
+		}), nil
+	}
+
+	return nil, errors.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+}
+
+// GetIndex returns the value of the key. Unlike Get, it fails if the key is
+// missing. Errors are reported at the bracket token.
+func (m *Map) GetIndex(bracket token.Token, key any) (any, error) {
+	if err := checkKey(key); err != nil {
+		return nil, errors.NewRuntimeError(bracket, err.Error())
+	}
+	value, ok := m.values[mapKey(key)]
+	if !ok {
+		return nil, errors.NewRuntimeError(bracket, "Undefined key.")
+	}
+	return value, nil
+}
+
+// SetIndex is like Set, but reports errors at the bracket token.
+func (m *Map) SetIndex(bracket token.Token, key any, value any) error {
+	if err := checkKey(key); err != nil {
+		return errors.NewRuntimeError(bracket, err.Error())
+	}
+	m.set(key, value)
+	return nil
+}
+
+func (m *Map) set(key any, value any) {
+	key = mapKey(key)
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *Map) delete(key any) any {
+	key = mapKey(key)
+	value, ok := m.values[key]
+	if !ok {
+		return nil
+	}
+	delete(m.values, key)
+	for index, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:index], m.keys[index+1:]...)
+			break
+		}
+	}
+	return value
+}
+
+// checkKey reports an error if the value can't be used as a map key.
+func checkKey(key any) error {
+	switch key.(type) {
+	case nil, string, int64, float64, bool:
+		return nil
+	}
+	return NewNativeError("Map keys must be strings, numbers, booleans or nil.")
+}
+
+// mapKey returns the key under which a value is stored. Numbers that are
+// equal are the same key, so whole floats are stored as integers.
+func mapKey(key any) any {
+	if number, ok := key.(float64); ok && number == math.Trunc(number) {
+		return floatToInteger(number)
+	}
+	return key
+}
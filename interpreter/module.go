@@ -0,0 +1,188 @@
+package interpreter
+
+import (
+	"fmt"
+	"interp/ast"
+	"interp/errors"
+	"interp/parser"
+	"interp/resolver"
+	"interp/scanner"
+	"interp/token"
+	"os"
+	"path/filepath"
+)
+
+// Globals are the global variables of a module.
+type Globals interface {
+	Lookup(name string) (any, bool)
+}
+
+// Module is the value of a module imported with import ... as. Only the
+// names the module exports can be read from it.
+type Module struct {
+	name    string
+	exports map[string]bool
+	globals Globals
+}
+
+func NewModule(name string, exports map[string]bool, globals Globals) *Module {
+	return &Module{name, exports, globals}
+}
+
+func (m *Module) Property(name token.Token) (any, error) {
+	if m.exports[name.Lexeme] {
+		if value, ok := m.globals.Lookup(name.Lexeme); ok {
+			return value, nil
+		}
+	}
+	return nil, errors.NewRuntimeError(name, fmt.Sprintf("Module \"%s\" doesn't export '%s'.", m.name, name.Lexeme))
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
+
+// ModuleFrame returns the name that the top level of the module imported as
+// path shows up as in stack traces.
+func ModuleFrame(path token.Token) string {
+	return fmt.Sprintf("<module %s>", (*path.Literal).(string))
+}
+
+// Modules finds, loads and caches the modules imported by scripts. Each
+// module is run once, by the first script that imports it.
+type Modules struct {
+	searchPath []string
+	// modules holds the modules by file. While a module is being run, it is
+	// there as nil.
+	modules map[string]*Module
+}
+
+func NewModules() *Modules {
+	return &Modules{modules: map[string]*Module{}}
+}
+
+// SetSearchPath sets the directories that modules are looked for in when
+// they aren't found relative to the script that imports them.
+func (m *Modules) SetSearchPath(directories []string) {
+	m.searchPath = directories
+}
+
+// SetEntry records the script that is run first, so that importing it is a
+// circular import rather than running it again. Scripts that aren't files
+// are ignored.
+func (m *Modules) SetEntry(file string) {
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		return
+	}
+	if file, err := filepath.Abs(file); err == nil {
+		m.modules[file] = nil
+	}
+}
+
+// RunModule runs the statements of a module that is imported for the first
+// time, and returns its globals. Errors found while compiling it are
+// reported to the diagnostics. The module runs as if it was called from path.
+type RunModule func(file string, path token.Token, statements []ast.Stmt, diagnostics *errors.Diagnostics) (Globals, error)
+
+// Import returns the module imported as path by the script in the file
+// importer, which is empty for scripts that aren't files. A module that
+// hasn't been imported before is parsed, resolved with locals and run.
+func (m *Modules) Import(importer string, path token.Token, locals resolver.Locals, run RunModule) (*Module, error) {
+	name := (*path.Literal).(string)
+
+	file, err := m.find(importer, name)
+	if err != nil {
+		return nil, errors.NewRuntimeError(path, fmt.Sprintf("Can't find module \"%s\".", name))
+	}
+
+	if module, ok := m.modules[file]; ok {
+		if module == nil {
+			return nil, errors.NewRuntimeError(path, fmt.Sprintf("Circular import of module \"%s\".", name))
+		}
+		return module, nil
+	}
+
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.NewRuntimeError(path, fmt.Sprintf("Can't read module \"%s\": %s.", name, err))
+	}
+
+	diagnostics := errors.NewDiagnostics(file)
+	statements := parse(string(source), locals, diagnostics)
+	if diagnostics.HasErrors() {
+		return nil, moduleError(path, name, diagnostics)
+	}
+
+	m.modules[file] = nil
+	globals, err := run(file, path, statements, diagnostics)
+	if diagnostics.HasErrors() {
+		delete(m.modules, file)
+		return nil, moduleError(path, name, diagnostics)
+	}
+	if err != nil {
+		delete(m.modules, file)
+		return nil, err
+	}
+
+	module := NewModule(name, exports(statements), globals)
+	m.modules[file] = module
+	return module, nil
+}
+
+// find returns the absolute path of the module imported as name. Relative
+// names are looked up next to the importer first, then in the search path.
+func (m *Modules) find(importer string, name string) (string, error) {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = []string{filepath.Join(filepath.Dir(importer), name)}
+		for _, directory := range m.searchPath {
+			candidates = append(candidates, filepath.Join(directory, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+	return "", os.ErrNotExist
+}
+
+func parse(source string, locals resolver.Locals, diagnostics *errors.Diagnostics) []ast.Stmt {
+	tokens, _ := scanner.NewScanner(source, diagnostics).ScanTokens()
+	if diagnostics.HasErrors() {
+		return nil
+	}
+
+	par := parser.NewParser(tokens, diagnostics)
+	statements, _ := par.Parse()
+	if diagnostics.HasErrors() {
+		return nil
+	}
+
+	res := resolver.NewResolver(locals, diagnostics)
+	_ = res.Resolve(statements)
+	return statements
+}
+
+// moduleError reports the first error found in a module at the import.
+func moduleError(path token.Token, name string, diagnostics *errors.Diagnostics) error {
+	for _, diagnostic := range diagnostics.Items() {
+		if diagnostic.Severity == errors.SeverityError {
+			return errors.NewRuntimeError(path, fmt.Sprintf("Can't import \"%s\": %s", name, diagnostic))
+		}
+	}
+	return nil
+}
+
+func exports(statements []ast.Stmt) map[string]bool {
+	names := map[string]bool{}
+	for _, statement := range statements {
+		if export, ok := statement.(*ast.ExportStmt); ok {
+			names[export.Name().Lexeme] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,52 @@
+package interpreter
+
+import (
+	"fmt"
+	"interp/token"
+)
+
+// Native is a function implemented in Go.
+type Native struct {
+	name     string
+	params   int
+	function func(interpreter *Interpreter, arguments []any) (any, error)
+}
+
+func NewNative(name string, params int, function func(interpreter *Interpreter, arguments []any) (any, error)) *Native {
+	return &Native{name, params, function}
+}
+
+func (n *Native) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	return n.function(interpreter, arguments)
+}
+
+func (n *Native) Arity() int {
+	return n.params
+}
+
+func (n *Native) String() string {
+	return "<native fn>"
+}
+
+// NativeError is returned by natives that fail. The interpreter turns it into
+// a runtime error reported at the call site.
+type NativeError struct {
+	message string
+}
+
+func NewNativeError(format string, a ...any) NativeError {
+	return NativeError{message: fmt.Sprintf(format, a...)}
+}
+
+func (n NativeError) Error() string {
+	return n.message
+}
+
+// CallSiteError is an error of a native that wants to know where it was
+// called. The interpreter passes it the closing parenthesis of each call it
+// returns through, innermost first. Unlike a NativeError, it doesn't become a
+// runtime error, so scripts can't catch it.
+type CallSiteError interface {
+	error
+	At(paren token.Token) error
+}
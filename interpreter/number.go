@@ -0,0 +1,257 @@
+package interpreter
+
+import (
+	"interp/token"
+	"math"
+	"strconv"
+)
+
+// Numbers are integers, which are int64, or floats, which are float64.
+// Arithmetic on integers is exact: it gives an integer, unless the result
+// doesn't fit in 64 bits or isn't whole, when it gives a float. Arithmetic
+// with a float gives a float. The bytecode VM shares these functions, so
+// that both backends compute the same.
+
+// IsNumber reports whether the value is a number.
+func IsNumber(value any) bool {
+	switch value.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+// Float returns a number as a float.
+func Float(value any) float64 {
+	switch value := value.(type) {
+	case int64:
+		return float64(value)
+	case float64:
+		return value
+	}
+	return 0
+}
+
+// Arithmetic applies an arithmetic operator to two numbers: plus, minus,
+// star, slash, percent, tilde slash or star star. Errors are NativeError.
+func Arithmetic(operator token.TokenType, left any, right any) (any, error) {
+	if !IsNumber(left) || !IsNumber(right) {
+		return nil, NewNativeError("Operands must be numbers.")
+	}
+	switch operator {
+	case token.Slash, token.Percent, token.TildeSlash:
+		if Float(right) == 0 {
+			return nil, NewNativeError("Can not divide by zero.")
+		}
+	}
+
+	a, aInteger := left.(int64)
+	b, bInteger := right.(int64)
+	if aInteger && bInteger {
+		return integerArithmetic(operator, a, b), nil
+	}
+
+	x, y := Float(left), Float(right)
+	switch operator {
+	case token.Plus:
+		return x + y, nil
+	case token.Minus:
+		return x - y, nil
+	case token.Star:
+		return x * y, nil
+	case token.Slash:
+		return x / y, nil
+	case token.Percent:
+		return floatModulo(x, y), nil
+	case token.TildeSlash:
+		return floatToInteger(math.Floor(x / y)), nil
+	case token.StarStar:
+		return math.Pow(x, y), nil
+	}
+	return nil, NewNativeError("Operands must be numbers.")
+}
+
+// integerArithmetic computes with integers, falling back to floats when the
+// result isn't an integer that fits. The divisor isn't zero.
+func integerArithmetic(operator token.TokenType, a int64, b int64) any {
+	switch operator {
+	case token.Plus:
+		sum := a + b
+		if (a^sum)&(b^sum) < 0 {
+			return float64(a) + float64(b)
+		}
+		return sum
+	case token.Minus:
+		difference := a - b
+		if (a^b)&(a^difference) < 0 {
+			return float64(a) - float64(b)
+		}
+		return difference
+	case token.Star:
+		if product, ok := multiply(a, b); ok {
+			return product
+		}
+		return float64(a) * float64(b)
+	case token.Slash:
+		if a%b == 0 && !(a == math.MinInt64 && b == -1) {
+			return a / b
+		}
+		return float64(a) / float64(b)
+	case token.Percent:
+		remainder := a % b
+		if remainder != 0 && (remainder < 0) != (b < 0) {
+			remainder += b
+		}
+		return remainder
+	case token.TildeSlash:
+		if a == math.MinInt64 && b == -1 {
+			return -float64(a)
+		}
+		quotient := a / b
+		if a%b != 0 && (a < 0) != (b < 0) {
+			quotient--
+		}
+		return quotient
+	case token.StarStar:
+		if power, ok := power(a, b); ok {
+			return power
+		}
+		return math.Pow(float64(a), float64(b))
+	}
+	return nil
+}
+
+// multiply multiplies two integers, and reports whether the product fits.
+func multiply(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// power raises an integer to a power, and reports whether the result is an
+// integer that fits.
+func power(base int64, exponent int64) (int64, bool) {
+	if exponent < 0 {
+		return 0, false
+	}
+	result := int64(1)
+	for exponent > 0 {
+		var ok bool
+		if exponent&1 == 1 {
+			if result, ok = multiply(result, base); !ok {
+				return 0, false
+			}
+		}
+		exponent >>= 1
+		if exponent > 0 {
+			if base, ok = multiply(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// floatModulo is the remainder of floored division, which has the sign of
+// the divisor as with integers.
+func floatModulo(x float64, y float64) float64 {
+	remainder := math.Mod(x, y)
+	if remainder != 0 && (remainder < 0) != (y < 0) {
+		remainder += y
+	}
+	return remainder
+}
+
+// floatToInteger returns a whole float as an integer if it fits.
+func floatToInteger(x float64) any {
+	if x >= -(1<<63) && x < 1<<63 {
+		return int64(x)
+	}
+	return x
+}
+
+// Comparison applies a comparison operator to two numbers: greater, greater
+// equal, less or less equal. Errors are NativeError.
+func Comparison(operator token.TokenType, left any, right any) (bool, error) {
+	if !IsNumber(left) || !IsNumber(right) {
+		return false, NewNativeError("Operands must be numbers.")
+	}
+	a, aInteger := left.(int64)
+	b, bInteger := right.(int64)
+	if aInteger && bInteger {
+		switch operator {
+		case token.Greater:
+			return a > b, nil
+		case token.GreaterEqual:
+			return a >= b, nil
+		case token.Less:
+			return a < b, nil
+		case token.LessEqual:
+			return a <= b, nil
+		}
+	}
+
+	x, y := Float(left), Float(right)
+	switch operator {
+	case token.Greater:
+		return x > y, nil
+	case token.GreaterEqual:
+		return x >= y, nil
+	case token.Less:
+		return x < y, nil
+	case token.LessEqual:
+		return x <= y, nil
+	}
+	return false, NewNativeError("Operands must be numbers.")
+}
+
+// Equal reports whether two values are equal, as the == operator does:
+// numbers by their value, whatever their type, and other values by
+// identity.
+func Equal(left any, right any) bool {
+	if IsNumber(left) && IsNumber(right) {
+		a, aInteger := left.(int64)
+		b, bInteger := right.(int64)
+		if aInteger && bInteger {
+			return a == b
+		}
+		return Float(left) == Float(right)
+	}
+	return left == right
+}
+
+// Negate negates a number, and reports false if the value isn't one.
+func Negate(value any) (any, bool) {
+	switch value := value.(type) {
+	case int64:
+		if value == math.MinInt64 {
+			return -float64(value), true
+		}
+		return -value, true
+	case float64:
+		return -value, true
+	}
+	return nil, false
+}
+
+// formatFloat formats a float with as many digits as it takes to tell it
+// apart from other floats, in exponent notation when it's very large or
+// small.
+func formatFloat(x float64) string {
+	switch {
+	case math.IsNaN(x):
+		return "nan"
+	case math.IsInf(x, 1):
+		return "inf"
+	case math.IsInf(x, -1):
+		return "-inf"
+	case x != 0 && (math.Abs(x) < 1e-6 || math.Abs(x) >= 1e21):
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
@@ -0,0 +1,365 @@
+package interpreter
+
+import (
+	"interp/token"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Builtins returns the natives that are defined in every global environment.
+func Builtins() map[string]Callable {
+	builtins := map[string]Callable{
+		"clock": NewClock(),
+	}
+	for _, native := range stdlib() {
+		builtins[native.name] = native
+	}
+	return builtins
+}
+
+func stdlib() []*Native {
+	return []*Native{
+		// Conversion
+		NewNative("str", 1, nativeStr),
+		NewNative("num", 1, nativeNum),
+		NewNative("type", 1, nativeType),
+
+		// Math
+		NewNative("floor", 1, roundingFunction("floor", math.Floor)),
+		NewNative("ceil", 1, roundingFunction("ceil", math.Ceil)),
+		NewNative("sqrt", 1, mathFunction("sqrt", math.Sqrt)),
+		NewNative("abs", 1, nativeAbs),
+		NewNative("pow", 2, nativePow),
+		NewNative("min", 2, extremum("min", token.Less)),
+		NewNative("max", 2, extremum("max", token.Greater)),
+		NewNative("random", 0, nativeRandom),
+		NewNative("seed", 1, nativeSeed),
+
+		// Strings
+		NewNative("len", 1, nativeLen),
+		NewNative("substr", 3, nativeSubstr),
+		NewNative("indexOf", 2, nativeIndexOf),
+		NewNative("split", 2, nativeSplit),
+		NewNative("join", 2, nativeJoin),
+		NewNative("upper", 1, stringFunction("upper", strings.ToUpper)),
+		NewNative("lower", 1, stringFunction("lower", strings.ToLower)),
+		NewNative("trim", 1, stringFunction("trim", strings.TrimSpace)),
+		NewNative("replace", 3, nativeReplace),
+
+		// Input
+		NewNative("readLine", 0, nativeReadLine),
+	}
+}
+
+func nativeStr(interpreter *Interpreter, arguments []any) (any, error) {
+	return interpreter.stringify(arguments[0]), nil
+}
+
+func nativeNum(_ *Interpreter, arguments []any) (any, error) {
+	switch value := arguments[0].(type) {
+	case int64, float64:
+		return value, nil
+	case string:
+		text := strings.TrimSpace(value)
+		if integer, err := strconv.ParseInt(text, 0, 64); err == nil {
+			return integer, nil
+		}
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, NewNativeError("Can't convert '%s' to a number.", value)
+		}
+		return number, nil
+	}
+	return nil, NewNativeError("Argument 1 of 'num' must be a number or a string.")
+}
+
+// TypeNamer is implemented by values that aren't defined by this package,
+// e.g. the ones of the bytecode VM, to tell type() their type.
+type TypeNamer interface {
+	TypeName() string
+}
+
+func nativeType(_ *Interpreter, arguments []any) (any, error) {
+	return TypeOf(arguments[0]), nil
+}
+
+// TypeOf returns the name of the type of a value, as type() returns it.
+func TypeOf(value any) string {
+	switch value := value.(type) {
+	case TypeNamer:
+		return value.TypeName()
+	case nil:
+		return "nil"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *List:
+		return "list"
+	case *Map:
+		return "map"
+	case *Error:
+		return "error"
+	case *Module:
+		return "module"
+	case *Class:
+		return "class"
+	case *Instance:
+		return "instance"
+	case Callable:
+		return "function"
+	}
+	return "unknown"
+}
+
+func mathFunction(name string, function func(float64) float64) func(*Interpreter, []any) (any, error) {
+	return func(_ *Interpreter, arguments []any) (any, error) {
+		x, err := numberArgument(name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return function(x), nil
+	}
+}
+
+// roundingFunction rounds a number to a whole one, which is an integer if it
+// fits.
+func roundingFunction(name string, function func(float64) float64) func(*Interpreter, []any) (any, error) {
+	return func(_ *Interpreter, arguments []any) (any, error) {
+		if _, err := numberArgument(name, arguments, 0); err != nil {
+			return nil, err
+		}
+		if integer, ok := arguments[0].(int64); ok {
+			return integer, nil
+		}
+		rounded := function(arguments[0].(float64))
+		if math.IsNaN(rounded) || math.IsInf(rounded, 0) {
+			return rounded, nil
+		}
+		return floatToInteger(rounded), nil
+	}
+}
+
+func nativeAbs(_ *Interpreter, arguments []any) (any, error) {
+	if _, err := numberArgument("abs", arguments, 0); err != nil {
+		return nil, err
+	}
+	if integer, ok := arguments[0].(int64); ok && integer < 0 {
+		negated, _ := Negate(integer)
+		return negated, nil
+	}
+	if _, ok := arguments[0].(int64); ok {
+		return arguments[0], nil
+	}
+	return math.Abs(arguments[0].(float64)), nil
+}
+
+func nativePow(_ *Interpreter, arguments []any) (any, error) {
+	for index := range arguments {
+		if _, err := numberArgument("pow", arguments, index); err != nil {
+			return nil, err
+		}
+	}
+	return Arithmetic(token.StarStar, arguments[0], arguments[1])
+}
+
+// extremum returns the smaller or the greater of two numbers, keeping its
+// type.
+func extremum(name string, operator token.TokenType) func(*Interpreter, []any) (any, error) {
+	return func(_ *Interpreter, arguments []any) (any, error) {
+		for index := range arguments {
+			if _, err := numberArgument(name, arguments, index); err != nil {
+				return nil, err
+			}
+		}
+		if math.IsNaN(Float(arguments[0])) || math.IsNaN(Float(arguments[1])) {
+			return math.NaN(), nil
+		}
+		first, _ := Comparison(operator, arguments[0], arguments[1])
+		if first {
+			return arguments[0], nil
+		}
+		return arguments[1], nil
+	}
+}
+
+func nativeRandom(interpreter *Interpreter, _ []any) (any, error) {
+	return interpreter.random.Float64(), nil
+}
+
+func nativeSeed(interpreter *Interpreter, arguments []any) (any, error) {
+	seed, err := numberArgument("seed", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	interpreter.random = rand.New(rand.NewSource(int64(seed)))
+	return nil, nil
+}
+
+func nativeLen(_ *Interpreter, arguments []any) (any, error) {
+	switch value := arguments[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(value)), nil
+	case *List:
+		return int64(len(value.elements)), nil
+	case *Map:
+		return int64(len(value.keys)), nil
+	}
+	return nil, NewNativeError("Argument 1 of 'len' must be a string, a list or a map.")
+}
+
+// nativeSubstr returns the characters of a string from start up to, but not
+// including, end. Negative positions count from the end of the string.
+func nativeSubstr(_ *Interpreter, arguments []any) (any, error) {
+	text, err := stringArgument("substr", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(text)
+
+	start, err := integerArgument("substr", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	end, err := integerArgument("substr", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	start, _ = sliceBound(int64(start), len(runes))
+	end, _ = sliceBound(int64(end), len(runes))
+	if start >= end {
+		return "", nil
+	}
+	return string(runes[start:end]), nil
+}
+
+func nativeIndexOf(_ *Interpreter, arguments []any) (any, error) {
+	text, err := stringArgument("indexOf", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	search, err := stringArgument("indexOf", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	index := strings.Index(text, search)
+	if index < 0 {
+		return int64(-1), nil
+	}
+	return int64(utf8.RuneCountInString(text[:index])), nil
+}
+
+func nativeSplit(_ *Interpreter, arguments []any) (any, error) {
+	text, err := stringArgument("split", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	separator, err := stringArgument("split", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(text, separator)
+	elements := make([]any, len(parts))
+	for index, part := range parts {
+		elements[index] = part
+	}
+	return NewList(elements), nil
+}
+
+func nativeJoin(interpreter *Interpreter, arguments []any) (any, error) {
+	list, ok := arguments[0].(*List)
+	if !ok {
+		return nil, NewNativeError("Argument 1 of 'join' must be a list.")
+	}
+	separator, err := stringArgument("join", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, len(list.elements))
+	for index, element := range list.elements {
+		parts[index] = interpreter.stringify(element)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+func stringFunction(name string, function func(string) string) func(*Interpreter, []any) (any, error) {
+	return func(_ *Interpreter, arguments []any) (any, error) {
+		text, err := stringArgument(name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return function(text), nil
+	}
+}
+
+func nativeReplace(_ *Interpreter, arguments []any) (any, error) {
+	text, err := stringArgument("replace", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	old, err := stringArgument("replace", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringArgument("replace", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(text, old, replacement), nil
+}
+
+// nativeReadLine reads a line from standard input, without the line ending.
+// It returns nil once the input is exhausted.
+func nativeReadLine(interpreter *Interpreter, _ []any) (any, error) {
+	line, err := interpreter.stdin.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, NewNativeError("Failed to read line: %s.", err)
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// numberArgument returns a number argument as a float.
+func numberArgument(name string, arguments []any, index int) (float64, error) {
+	if !IsNumber(arguments[index]) {
+		return 0, NewNativeError("Argument %d of '%s' must be a number.", index+1, name)
+	}
+	return Float(arguments[index]), nil
+}
+
+func integerArgument(name string, arguments []any, index int) (int, error) {
+	if integer, ok := arguments[index].(int64); ok {
+		return int(integer), nil
+	}
+	number, err := numberArgument(name, arguments, index)
+	if err != nil {
+		return 0, err
+	}
+	if number != math.Trunc(number) {
+		return 0, NewNativeError("Argument %d of '%s' must be an integer.", index+1, name)
+	}
+	return int(number), nil
+}
+
+func stringArgument(name string, arguments []any, index int) (string, error) {
+	text, ok := arguments[index].(string)
+	if !ok {
+		return "", NewNativeError("Argument %d of '%s' must be a string.", index+1, name)
+	}
+	return text, nil
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"interp/ast"
 	"interp/environment"
+	"interp/errors"
+	"interp/token"
 )
 
 func (i *Interpreter) VisitExpressionStmt(stmt *ast.ExpressionStmt) (any, error) {
@@ -15,7 +17,7 @@ func (i *Interpreter) VisitExpressionStmt(stmt *ast.ExpressionStmt) (any, error)
 }
 
 func (i *Interpreter) VisitFunctionStmt(stmt *ast.FunctionStmt) (any, error) {
-	function := NewFunction(stmt, i.environment, false)
+	function := NewFunction(stmt, i.environment, false, i.file)
 	i.environment.Define(stmt.Name.Lexeme, function)
 	return nil, nil
 }
@@ -47,7 +49,10 @@ func (i *Interpreter) VisitPrintStmt(stmt *ast.PrintStmt) (any, error) {
 		return nil, err
 	}
 
-	fmt.Println(i.stringify(value))
+	_, err = fmt.Fprintln(i.stdout, i.stringify(value))
+	if err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -94,7 +99,15 @@ func (i *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) (any, error) {
 			if _, ok := err.(Break); ok {
 				return nil, nil
 			}
-			return nil, err
+			if _, ok := err.(Continue); !ok {
+				return nil, err
+			}
+		}
+		if stmt.Increment != nil {
+			_, err = i.evaluate(stmt.Increment)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	return nil, nil
@@ -105,18 +118,116 @@ func (i *Interpreter) VisitBlockStmt(stmt *ast.BlockStmt) (any, error) {
 }
 
 func (i *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
-	i.environment.Define(stmt.Name.Lexeme, nil)
+	var superclass *Class
+	if stmt.Superclass != nil {
+		value, err := i.evaluate(stmt.Superclass)
+		if err != nil {
+			return nil, err
+		}
+		class, ok := value.(*Class)
+		if !ok {
+			return nil, errors.NewRuntimeError(stmt.Superclass.Name, "Superclass must be a class.")
+		}
+		superclass = class
+	}
+
+	if superclass != nil {
+		i.environment = environment.NewEnvironment(i.environment)
+		i.environment.Define("super", superclass)
+	}
 
 	methods := map[string]*Function{}
 	for _, method := range stmt.Methods {
-		function := NewFunction(method, i.environment, method.Name.Lexeme == "init")
+		function := NewFunction(method, i.environment, method.Name.Lexeme == "init", i.file)
 		methods[method.Name.Lexeme] = function
 	}
 
-	class := NewClass(stmt.Name.Lexeme, methods)
-	return nil, i.environment.Assign(stmt.Name, class)
+	class := NewClass(stmt.Name.Lexeme, superclass, methods)
+
+	if superclass != nil {
+		i.environment = i.environment.Enclosing()
+	}
+
+	// The class is only defined now that it exists. Its methods can already
+	// refer to it, but they can't be called before it is defined.
+	i.environment.Define(stmt.Name.Lexeme, class)
+	return nil, nil
 }
 
 func (i *Interpreter) VisitBreakStmt(stmt *ast.BreakStmt) (any, error) {
 	return nil, Break{}
 }
+
+func (i *Interpreter) VisitContinueStmt(stmt *ast.ContinueStmt) (any, error) {
+	return nil, Continue{}
+}
+
+func (i *Interpreter) VisitThrowStmt(stmt *ast.ThrowStmt) (any, error) {
+	value, err := i.evaluate(stmt.Value)
+	if err != nil {
+		return nil, err
+	}
+	return nil, Throw{Value: value, Keyword: stmt.Keyword, stack: i.stackTrace(stmt.Keyword)}
+}
+
+// VisitTryStmt catches thrown values and runtime errors. Break, continue and
+// return pass through, but the finally block still runs on their way out.
+func (i *Interpreter) VisitTryStmt(stmt *ast.TryStmt) (any, error) {
+	_, err := i.execute(stmt.Body)
+
+	if stmt.Catch != nil {
+		if caught, ok := Caught(i.withStack(err)); ok {
+			env := environment.NewEnvironment(i.environment)
+			env.Define(stmt.Catch.Name.Lexeme, caught)
+			err = i.executeBlock(stmt.Catch.Body, env)
+		}
+	}
+
+	if stmt.Finally != nil {
+		_, finallyErr := i.execute(stmt.Finally)
+		if finallyErr != nil {
+			return nil, finallyErr
+		}
+	}
+
+	return nil, err
+}
+
+func (i *Interpreter) VisitImportStmt(stmt *ast.ImportStmt) (any, error) {
+	module, err := i.modules.Import(i.file, stmt.Path, i, i.runModule)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Alias != nil {
+		i.environment.Define(stmt.Alias.Lexeme, module)
+	}
+	for _, name := range stmt.Names {
+		value, err := module.Property(name)
+		if err != nil {
+			return nil, err
+		}
+		i.environment.Define(name.Lexeme, value)
+	}
+	return nil, nil
+}
+
+// runModule runs a module in its own global environment. It shows up in stack
+// traces as a call made by the import.
+func (i *Interpreter) runModule(file string, path token.Token, statements []ast.Stmt, _ *errors.Diagnostics) (Globals, error) {
+	globals := environment.NewEnvironment(nil)
+	for name, builtin := range Builtins() {
+		globals.Define(name, builtin)
+	}
+
+	i.frames = append(i.frames, frame{ModuleFrame(path), path, i.file, i.environment})
+	i.file = file
+	err := i.withStack(i.executeBlock(statements, globals))
+	i.file = i.frames[len(i.frames)-1].file
+	i.frames = i.frames[:len(i.frames)-1]
+	return globals, err
+}
+
+func (i *Interpreter) VisitExportStmt(stmt *ast.ExportStmt) (any, error) {
+	return i.execute(stmt.Declaration)
+}
@@ -0,0 +1,42 @@
+package interpreter
+
+import (
+	"fmt"
+	"interp/errors"
+	"interp/token"
+)
+
+// Throw carries a thrown value up to the try statement that catches it.
+type Throw struct {
+	Value   any
+	Keyword token.Token
+	stack   []errors.StackFrame
+}
+
+func NewThrow(value any, keyword token.Token, stack []errors.StackFrame) Throw {
+	return Throw{value, keyword, stack}
+}
+
+// Stack returns the calls that were in progress when the value was thrown,
+// innermost first.
+func (t Throw) Stack() []errors.StackFrame {
+	return t.stack
+}
+
+func (t Throw) Error() string {
+	return fmt.Sprintf("throw %v", t.Value)
+}
+
+// Caught returns the value a catch clause binds for the error, and whether
+// the error can be caught at all. Runtime errors are caught as Error values.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func Caught(err error) (any, bool) {
+	switch err := err.(type) {
+	case Throw:
+		return err.Value, true
+	case errors.RuntimeError:
+		return NewError(err), true
+	}
+	return nil, false
+}
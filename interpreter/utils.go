@@ -2,8 +2,7 @@ package interpreter
 
 import (
 	"fmt"
-	"interp/errors"
-	"interp/token"
+	"strconv"
 	"strings"
 )
 
@@ -11,16 +10,38 @@ func (i *Interpreter) stringify(object any) string {
 	if object == nil {
 		return "nil"
 	}
-	if i.isFloat(object) {
-		text := fmt.Sprintf("%.2f", object)
-		if strings.HasSuffix(text, ".00") {
-			text = text[:len(text)-3]
+	switch number := object.(type) {
+	case int64:
+		return strconv.FormatInt(number, 10)
+	case float64:
+		return formatFloat(number)
+	}
+	if list, ok := object.(*List); ok {
+		elements := make([]string, len(list.elements))
+		for index, element := range list.elements {
+			elements[index] = i.stringifyElement(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+	if m, ok := object.(*Map); ok {
+		entries := make([]string, len(m.keys))
+		for index, key := range m.keys {
+			entries[index] = i.stringifyElement(key) + ": " + i.stringifyElement(m.values[key])
 		}
-		return text
+		return "{" + strings.Join(entries, ", ") + "}"
 	}
 	return fmt.Sprintf("%v", object)
 }
 
+// stringifyElement is like stringify, but quotes strings so that they can be
+// told apart from other values inside a collection.
+func (i *Interpreter) stringifyElement(object any) string {
+	if text, ok := object.(string); ok {
+		return strconv.Quote(text)
+	}
+	return i.stringify(object)
+}
+
 func (i *Interpreter) isTruthy(object any) bool {
 	if object == nil {
 		return false
@@ -28,32 +49,13 @@ func (i *Interpreter) isTruthy(object any) bool {
 	if b, ok := object.(bool); ok {
 		return b
 	}
-	if f, ok := object.(float64); ok && f == 0 {
+	if IsNumber(object) && Float(object) == 0 {
 		return false
 	}
 	return true
 }
 
-func (i *Interpreter) isFloat(object any) bool {
-	_, ok := object.(float64)
-	return ok
-}
-
 func (i *Interpreter) isString(object any) bool {
 	_, ok := object.(string)
 	return ok
 }
-
-func (i *Interpreter) checkNumberOperand(operator token.Token, operand any) error {
-	if i.isFloat(operand) {
-		return nil
-	}
-	return errors.NewRuntimeError(operator, "Operand must be a number.")
-}
-
-func (i *Interpreter) checkNumberOperands(operator token.Token, left any, right any) error {
-	if i.isFloat(left) && i.isFloat(right) {
-		return nil
-	}
-	return errors.NewRuntimeError(operator, "Operands must be numbers.")
-}
@@ -0,0 +1,490 @@
+package lsp
+
+import (
+	"interp/ast"
+	"interp/errors"
+	"interp/parser"
+	"interp/resolver"
+	"interp/scanner"
+	"interp/token"
+	"sort"
+	"strings"
+)
+
+type kind int
+
+const (
+	kindVariable kind = iota
+	kindParameter
+	kindFunction
+	kindClass
+	kindMethod
+	kindImport
+)
+
+// place identifies a name by where it is in the source.
+type place struct {
+	line   int
+	column int
+}
+
+func placeOf(token token.Token) place {
+	return place{token.Line, token.Column}
+}
+
+// declaration is a name declared in a document.
+type declaration struct {
+	name token.Token
+	kind kind
+	// function is the function of a function, method or parameter.
+	function *ast.FunctionStmt
+	// class is the class of a class or method.
+	class *ast.ClassStmt
+	// params are the parameters of a lambda a parameter belongs to.
+	params []token.Token
+}
+
+// use is a name that refers to a variable. Declarations are uses of
+// themselves. The declaration of a global that isn't declared in the
+// document, such as a builtin, is nil.
+type use struct {
+	name        token.Token
+	declaration *place
+}
+
+// document is an open source file, analysed every time it changes.
+type document struct {
+	source string
+	lines  []string
+	// utf16 is set when the client counts characters in UTF-16 code units.
+	utf16       bool
+	statements  []ast.Stmt
+	diagnostics []errors.Diagnostic
+	// broken is set when the source has syntax errors, so only the parts
+	// that could be parsed have been analysed.
+	broken       bool
+	declarations map[place]*declaration
+	uses         []use
+	// properties are the names of the properties and methods accessed,
+	// which are only looked up by name when the script runs.
+	properties []token.Token
+	// globals are the top-level declarations by name.
+	globals map[string]place
+	// keptNames and keptProperties are the names that can be completed in
+	// the last version of a broken document, whose parts that don't parse
+	// are still being typed.
+	keptNames      map[string]int
+	keptProperties []string
+	// unresolved are the uses of globals, which are linked to the
+	// declarations by name once the resolver is done.
+	unresolved []token.Token
+}
+
+func analyse(source string) *document {
+	d := &document{source: source, lines: strings.Split(source, "\n"), declarations: map[place]*declaration{}, globals: map[string]place{}}
+
+	diagnostics := errors.NewDiagnostics("")
+	tokens, _ := scanner.NewScanner(source, diagnostics).ScanTokens()
+	par := parser.NewParser(tokens, diagnostics)
+	d.statements, _ = par.Parse()
+	d.broken = diagnostics.HasErrors()
+
+	// Warnings about what couldn't be parsed, such as unused variables, would
+	// be wrong, so the resolver only reports when the whole source parsed.
+	checks := errors.NewDiagnostics("")
+	res := resolver.NewResolver(symbols{d}, checks)
+	res.SetSymbols(symbols{d})
+	_ = res.Resolve(d.statements)
+	d.diagnostics = diagnostics.Items()
+	if !d.broken {
+		d.diagnostics = append(d.diagnostics, checks.Items()...)
+	}
+
+	d.declare()
+	for _, name := range d.unresolved {
+		if declaration, ok := d.globals[name.Lexeme]; ok {
+			d.uses = append(d.uses, use{name, &declaration})
+		} else {
+			d.uses = append(d.uses, use{name, nil})
+		}
+	}
+	return d
+}
+
+// symbols records what the resolver finds. The slots of locals aren't
+// needed.
+type symbols struct {
+	document *document
+}
+
+func (s symbols) Resolve(ast.Expr, int, int) {}
+
+func (s symbols) Use(name token.Token, declaration *token.Token) {
+	if name.Type == token.This || name.Type == token.Super {
+		return
+	}
+	if declaration == nil {
+		s.document.unresolved = append(s.document.unresolved, name)
+		return
+	}
+	at := placeOf(*declaration)
+	s.document.uses = append(s.document.uses, use{name, &at})
+}
+
+// declare finds the declarations and the properties used in the document.
+func (d *document) declare() {
+	for _, stmt := range d.statements {
+		if export, ok := stmt.(*ast.ExportStmt); ok {
+			stmt = export.Declaration
+		}
+		for _, name := range declaredNames(stmt) {
+			if _, ok := d.globals[name.Lexeme]; !ok {
+				d.globals[name.Lexeme] = placeOf(name)
+			}
+		}
+	}
+
+	methods := map[*ast.FunctionStmt]*ast.ClassStmt{}
+	ast.Inspect(d.statements, func(node any) bool {
+		switch node := node.(type) {
+		case *ast.FunctionStmt:
+			if class, ok := methods[node]; ok {
+				d.add(&declaration{name: node.Name, kind: kindMethod, function: node, class: class})
+			} else {
+				d.add(&declaration{name: node.Name, kind: kindFunction, function: node})
+			}
+			for _, param := range node.Params {
+				d.add(&declaration{name: param, kind: kindParameter, function: node})
+			}
+		case *ast.LambdaExpr:
+			for _, param := range node.Params {
+				d.add(&declaration{name: param, kind: kindParameter, params: node.Params})
+			}
+		case *ast.ClassStmt:
+			d.add(&declaration{name: node.Name, kind: kindClass, class: node})
+			for _, method := range node.Methods {
+				methods[method] = node
+			}
+		case *ast.VarStmt:
+			d.add(&declaration{name: node.Name, kind: kindVariable})
+		case *ast.CatchClause:
+			d.add(&declaration{name: node.Name, kind: kindVariable})
+		case *ast.ImportStmt:
+			for _, name := range declaredNames(node) {
+				d.add(&declaration{name: name, kind: kindImport})
+			}
+		case *ast.GetExpr:
+			d.properties = append(d.properties, node.Name)
+		case *ast.SetExpr:
+			d.properties = append(d.properties, node.Name)
+		case *ast.SuperExpr:
+			d.properties = append(d.properties, node.Method)
+		}
+		return true
+	})
+}
+
+// add records a declaration. Except for methods, which are properties, a
+// declaration is also a use of its name.
+func (d *document) add(declaration *declaration) {
+	at := placeOf(declaration.name)
+	d.declarations[at] = declaration
+	if declaration.kind != kindMethod {
+		d.uses = append(d.uses, use{declaration.name, &at})
+	}
+}
+
+// declaredNames returns the names a top-level statement declares.
+func declaredNames(stmt ast.Stmt) []token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.FunctionStmt:
+		return []token.Token{stmt.Name}
+	case *ast.ClassStmt:
+		return []token.Token{stmt.Name}
+	case *ast.VarStmt:
+		return []token.Token{stmt.Name}
+	case *ast.ImportStmt:
+		if stmt.Alias != nil {
+			return []token.Token{*stmt.Alias}
+		}
+		return stmt.Names
+	}
+	return nil
+}
+
+// useAt returns the variable whose name is at the position.
+func (d *document) useAt(position Position) (use, bool) {
+	for _, use := range d.uses {
+		if d.contains(use.name, position) {
+			return use, true
+		}
+	}
+	return use{}, false
+}
+
+// declarationOf returns the declaration a variable refers to, if it is
+// declared in the document.
+func (d *document) declarationOf(use use) *declaration {
+	if use.declaration == nil {
+		return nil
+	}
+	return d.declarations[*use.declaration]
+}
+
+// propertyAt returns the name of the property or method at the position.
+func (d *document) propertyAt(position Position) (token.Token, bool) {
+	for _, property := range d.properties {
+		if d.contains(property, position) {
+			return property, true
+		}
+	}
+	for _, declaration := range d.declarations {
+		if declaration.kind == kindMethod && d.contains(declaration.name, position) {
+			return declaration.name, true
+		}
+	}
+	return token.Token{}, false
+}
+
+// methods returns the methods with the name, in the order they are declared.
+func (d *document) methods(name string) []*declaration {
+	var methods []*declaration
+	for _, declaration := range d.declarations {
+		if declaration.kind == kindMethod && declaration.name.Lexeme == name {
+			methods = append(methods, declaration)
+		}
+	}
+	sortByPosition(methods)
+	return methods
+}
+
+// definitions returns the declarations of the name at the position. A
+// property can be any method with its name.
+func (d *document) definitions(position Position) []token.Token {
+	if use, ok := d.useAt(position); ok {
+		if declaration := d.declarationOf(use); declaration != nil {
+			return []token.Token{declaration.name}
+		}
+		return nil
+	}
+	if property, ok := d.propertyAt(position); ok {
+		var names []token.Token
+		for _, method := range d.methods(property.Lexeme) {
+			names = append(names, method.name)
+		}
+		return names
+	}
+	return nil
+}
+
+// references returns the uses of the name at the position, in the order
+// they appear in the source.
+func (d *document) references(position Position, includeDeclaration bool) []token.Token {
+	var names []token.Token
+	if found, ok := d.useAt(position); ok {
+		for _, use := range d.uses {
+			if !sameDeclaration(use, found) {
+				continue
+			}
+			if !includeDeclaration && use.declaration != nil && placeOf(use.name) == *use.declaration {
+				continue
+			}
+			names = append(names, use.name)
+		}
+	} else if property, ok := d.propertyAt(position); ok {
+		for _, name := range d.properties {
+			if name.Lexeme == property.Lexeme {
+				names = append(names, name)
+			}
+		}
+		if includeDeclaration {
+			for _, method := range d.methods(property.Lexeme) {
+				names = append(names, method.name)
+			}
+		}
+	}
+	sortTokens(names)
+	return names
+}
+
+func sameDeclaration(a use, b use) bool {
+	if a.declaration == nil || b.declaration == nil {
+		return a.declaration == nil && b.declaration == nil && a.name.Lexeme == b.name.Lexeme
+	}
+	return *a.declaration == *b.declaration
+}
+
+// hover describes the name at the position and returns its token.
+func (d *document) hover(position Position, builtins map[string]int) (string, token.Token, bool) {
+	if use, ok := d.useAt(position); ok {
+		if declaration := d.declarationOf(use); declaration != nil {
+			return declaration.signature(), use.name, true
+		}
+		if arity, ok := builtins[use.name.Lexeme]; ok {
+			return "native " + nativeSignature(use.name.Lexeme, arity), use.name, true
+		}
+		return "", token.Token{}, false
+	}
+	if property, ok := d.propertyAt(position); ok {
+		var signatures []string
+		for _, method := range d.methods(property.Lexeme) {
+			signatures = append(signatures, method.signature())
+		}
+		if len(signatures) > 0 {
+			return strings.Join(signatures, "\n"), property, true
+		}
+	}
+	return "", token.Token{}, false
+}
+
+// signature describes a declaration the way it is written.
+func (d *declaration) signature() string {
+	switch d.kind {
+	case kindFunction:
+		return "fun " + d.name.Lexeme + params(d.function.Params)
+	case kindMethod:
+		return "method " + d.class.Name.Lexeme + "." + d.name.Lexeme + params(d.function.Params)
+	case kindClass:
+		signature := "class " + d.name.Lexeme
+		if d.class.Superclass != nil {
+			signature += " < " + d.class.Superclass.Name.Lexeme
+		}
+		for _, method := range d.class.Methods {
+			if method.Name.Lexeme == "init" {
+				signature += "\n" + d.name.Lexeme + params(method.Params)
+			}
+		}
+		return signature
+	case kindParameter:
+		if d.function != nil {
+			return "parameter " + d.name.Lexeme + " of fun " + d.function.Name.Lexeme + params(d.function.Params)
+		}
+		return "parameter " + d.name.Lexeme + " of fun" + params(d.params)
+	case kindImport:
+		return "import " + d.name.Lexeme
+	}
+	return "var " + d.name.Lexeme
+}
+
+// nativeSignature describes a native, whose parameters have no names.
+func nativeSignature(name string, arity int) string {
+	params := make([]string, arity)
+	for i := range params {
+		params[i] = "_"
+	}
+	return "fun " + name + "(" + strings.Join(params, ", ") + ")"
+}
+
+func params(params []token.Token) string {
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Lexeme
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+// symbols returns the classes and functions declared at the top level.
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, stmt := range d.statements {
+		if export, ok := stmt.(*ast.ExportStmt); ok {
+			stmt = export.Declaration
+		}
+		switch stmt := stmt.(type) {
+		case *ast.FunctionStmt:
+			symbols = append(symbols, d.functionSymbol(stmt, symbolFunction))
+		case *ast.ClassStmt:
+			symbol := DocumentSymbol{
+				Name:           stmt.Name.Lexeme,
+				Kind:           symbolClass,
+				Range:          d.tokenRange(stmt.Name),
+				SelectionRange: d.tokenRange(stmt.Name),
+			}
+			if stmt.Superclass != nil {
+				symbol.Detail = "< " + stmt.Superclass.Name.Lexeme
+			}
+			for _, method := range stmt.Methods {
+				symbol.Children = append(symbol.Children, d.functionSymbol(method, symbolMethod))
+			}
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+func (d *document) functionSymbol(function *ast.FunctionStmt, kind int) DocumentSymbol {
+	return DocumentSymbol{
+		Name:           function.Name.Lexeme,
+		Detail:         params(function.Params),
+		Kind:           kind,
+		Range:          d.tokenRange(function.Name),
+		SelectionRange: d.tokenRange(function.Name),
+	}
+}
+
+// names returns the names declared in the document that can be completed,
+// with their completion kinds.
+func (d *document) names() map[string]int {
+	names := map[string]int{}
+	for name, kind := range d.keptNames {
+		names[name] = kind
+	}
+	for _, declaration := range d.declarations {
+		switch declaration.kind {
+		case kindFunction:
+			names[declaration.name.Lexeme] = completionFunction
+		case kindClass:
+			names[declaration.name.Lexeme] = completionClass
+		case kindMethod:
+		default:
+			names[declaration.name.Lexeme] = completionVariable
+		}
+	}
+	return names
+}
+
+// keep keeps the names that can be completed in the previous version of the
+// document.
+func (d *document) keep(previous *document) {
+	d.keptNames = previous.names()
+	d.keptProperties = previous.propertyNames()
+}
+
+// propertyNames returns the names of the properties and methods used or
+// declared in the document, sorted.
+func (d *document) propertyNames() []string {
+	seen := map[string]bool{}
+	for _, name := range d.keptProperties {
+		seen[name] = true
+	}
+	for _, property := range d.properties {
+		seen[property.Lexeme] = true
+	}
+	for _, declaration := range d.declarations {
+		if declaration.kind == kindMethod && declaration.name.Lexeme != "init" {
+			seen[declaration.name.Lexeme] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortByPosition(declarations []*declaration) {
+	sort.Slice(declarations, func(i, j int) bool {
+		return before(declarations[i].name, declarations[j].name)
+	})
+}
+
+func sortTokens(tokens []token.Token) {
+	sort.Slice(tokens, func(i, j int) bool {
+		return before(tokens[i], tokens[j])
+	})
+}
+
+func before(a token.Token, b token.Token) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
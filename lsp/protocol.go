@@ -0,0 +1,233 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"interp/token"
+	"io"
+	"net/textproto"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Error codes of JSON-RPC and the language server protocol.
+const (
+	codeParseError           = -32700
+	codeInvalidRequest       = -32600
+	codeMethodNotFound       = -32601
+	codeInvalidParams        = -32602
+	codeServerNotInitialized = -32002
+)
+
+// message is a request or a notification sent by the client. Notifications
+// have no ID.
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// readMessage reads the content of the next message, which follows a
+// Content-Length header and an empty line.
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func writeMessage(w io.Writer, message map[string]any) {
+	message["jsonrpc"] = "2.0"
+	content, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(content), content)
+}
+
+// Position is a position in a document. Lines and characters start at 0.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// tokenRange returns the range of a token in the document, in the
+// characters of the client.
+func (d *document) tokenRange(token token.Token) Range {
+	line := token.Line - 1
+	start := Position{line, d.character(line, token.Column)}
+	return Range{start, Position{line, d.character(line, token.Column+utf8.RuneCountInString(token.Lexeme))}}
+}
+
+// contains reports whether the token covers the position, including the
+// position just after it, where the cursor is after typing it.
+func (d *document) contains(token token.Token, position Position) bool {
+	r := d.tokenRange(token)
+	return r.Start.Line == position.Line && r.Start.Character <= position.Character && position.Character <= r.End.Character
+}
+
+// character converts a column of a line, which the scanner counts in code
+// points, to a character of the client, which counts UTF-16 code units
+// unless it accepts UTF-32. Columns past the end of the line count one each.
+func (d *document) character(line int, column int) int {
+	if !d.utf16 || line < 0 || line >= len(d.lines) {
+		return column
+	}
+	character := 0
+	for _, r := range d.lines[line] {
+		if column == 0 {
+			break
+		}
+		character += utf16Length(r)
+		column--
+	}
+	return character + column
+}
+
+// column converts the character of a position to a column in code points.
+func (d *document) column(position Position) int {
+	if !d.utf16 || position.Line < 0 || position.Line >= len(d.lines) {
+		return position.Character
+	}
+	column, character := 0, 0
+	for _, r := range d.lines[position.Line] {
+		if character >= position.Character {
+			return column
+		}
+		character += utf16Length(r)
+		column++
+	}
+	return column + max(0, position.Character-character)
+}
+
+// utf16Length returns the number of UTF-16 code units that encode the code
+// point.
+func utf16Length(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+type initializeParams struct {
+	Capabilities struct {
+		General struct {
+			PositionEncodings []string `json:"positionEncodings"`
+		} `json:"general"`
+	} `json:"capabilities"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type referenceParams struct {
+	textDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type documentParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+// Severities of diagnostics.
+const (
+	severityError   = 1
+	severityWarning = 2
+)
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// Kinds of document symbols.
+const (
+	symbolClass    = 5
+	symbolMethod   = 6
+	symbolFunction = 12
+)
+
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    Range         `json:"range"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Kinds of completion items.
+const (
+	completionFunction = 3
+	completionVariable = 6
+	completionClass    = 7
+	completionProperty = 10
+	completionKeyword  = 14
+)
+
+type CompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
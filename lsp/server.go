@@ -0,0 +1,322 @@
+// Package lsp implements a Language Server Protocol server, which gives
+// editors diagnostics, navigation, hovers and completion for scripts.
+//
+// Documents are analysed whole every time they change: they are scanned,
+// parsed and resolved, and the resolver tells which declaration every
+// variable refers to. Properties and methods are looked up by name when a
+// script runs, so they are linked to every method with their name.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/token"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrExit is returned by Serve when the client asked the server to exit
+// without shutting it down first.
+var ErrExit = exitError{}
+
+type exitError struct{}
+
+func (exitError) Error() string {
+	return "Exited before shutdown."
+}
+
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	documents   map[string]*document
+	initialized bool
+	shutdown    bool
+	// utf16 is set unless the client accepts characters counted in code
+	// points, like the scanner counts columns.
+	utf16 bool
+	// builtins are the arities of the natives, by name.
+	builtins map[string]int
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	builtins := map[string]int{}
+	for name, native := range interpreter.Builtins() {
+		builtins[name] = native.Arity()
+	}
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		documents: map[string]*document{},
+		builtins:  builtins,
+	}
+}
+
+// Serve handles messages until the client sends exit or the input ends.
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var message message
+		if err := json.Unmarshal(content, &message); err != nil {
+			writeMessage(s.out, map[string]any{
+				"id":    nil,
+				"error": responseError{codeParseError, err.Error()},
+			})
+			continue
+		}
+
+		if message.Method == "exit" {
+			if !s.shutdown {
+				return ErrExit
+			}
+			return nil
+		}
+		if len(message.ID) == 0 {
+			s.notify(message)
+			continue
+		}
+
+		result, failure := s.handle(message)
+		if failure != nil {
+			writeMessage(s.out, map[string]any{"id": message.ID, "error": failure})
+		} else {
+			writeMessage(s.out, map[string]any{"id": message.ID, "result": result})
+		}
+	}
+}
+
+// handle handles a request and returns its result.
+func (s *Server) handle(message message) (any, *responseError) {
+	if message.Method == "initialize" {
+		s.initialized = true
+		capabilities := map[string]any{
+			"textDocumentSync":       1,
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"documentSymbolProvider": true,
+			"hoverProvider":          true,
+			"completionProvider":     map[string]any{"triggerCharacters": []string{"."}},
+		}
+		// Positions are in UTF-16 code units unless the client accepts
+		// code points.
+		var params initializeParams
+		_ = json.Unmarshal(message.Params, &params)
+		s.utf16 = !slices.Contains(params.Capabilities.General.PositionEncodings, "utf-32")
+		if !s.utf16 {
+			capabilities["positionEncoding"] = "utf-32"
+		}
+		return map[string]any{
+			"capabilities": capabilities,
+			"serverInfo":   map[string]any{"name": "interp"},
+		}, nil
+	}
+	if !s.initialized {
+		return nil, &responseError{codeServerNotInitialized, "The server hasn't been initialized."}
+	}
+	if s.shutdown {
+		return nil, &responseError{codeInvalidRequest, "The server has been shut down."}
+	}
+
+	switch message.Method {
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/definition":
+		return withPosition(message, s.definition)
+	case "textDocument/references":
+		var params referenceParams
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		return s.references(params), nil
+	case "textDocument/documentSymbol":
+		var params documentParams
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		if document, ok := s.documents[params.TextDocument.URI]; ok {
+			return document.symbols(), nil
+		}
+		return []DocumentSymbol{}, nil
+	case "textDocument/hover":
+		return withPosition(message, s.hover)
+	case "textDocument/completion":
+		return withPosition(message, s.completion)
+	}
+	return nil, &responseError{codeMethodNotFound, "Unknown method " + message.Method + "."}
+}
+
+// withPosition decodes the document and position a request is about, and
+// handles it.
+func withPosition(message message, handler func(params textDocumentPositionParams) any) (any, *responseError) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(message.Params, &params); err != nil {
+		return nil, &responseError{codeInvalidParams, err.Error()}
+	}
+	return handler(params), nil
+}
+
+// notify handles a notification, which has no response.
+func (s *Server) notify(message message) {
+	if !s.initialized || s.shutdown {
+		return
+	}
+
+	switch message.Method {
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if json.Unmarshal(message.Params, &params) == nil {
+			s.update(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if json.Unmarshal(message.Params, &params) == nil && len(params.ContentChanges) > 0 {
+			// Documents are synchronised in full, so the last change has the
+			// whole text.
+			s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params documentParams
+		if json.Unmarshal(message.Params, &params) == nil {
+			delete(s.documents, params.TextDocument.URI)
+			s.publishDiagnostics(params.TextDocument.URI, nil)
+		}
+	}
+}
+
+// update analyses the new text of a document and publishes its diagnostics.
+func (s *Server) update(uri string, text string) {
+	document := analyse(text)
+	document.utf16 = s.utf16
+	if previous, ok := s.documents[uri]; ok && document.broken {
+		document.keep(previous)
+	}
+	s.documents[uri] = document
+	s.publishDiagnostics(uri, document)
+}
+
+// publishDiagnostics publishes the diagnostics of the document, or none if
+// it's nil.
+func (s *Server) publishDiagnostics(uri string, document *document) {
+	var diagnostics []errors.Diagnostic
+	if document != nil {
+		diagnostics = document.diagnostics
+	}
+	items := []Diagnostic{}
+	for _, diagnostic := range diagnostics {
+		severity := severityError
+		if diagnostic.Severity == errors.SeverityWarning {
+			severity = severityWarning
+		}
+		line, column := diagnostic.Line-1, diagnostic.Column-1
+		items = append(items, Diagnostic{
+			Range: Range{
+				Position{line, document.character(line, column)},
+				Position{line, document.character(line, column+max(1, diagnostic.Span))},
+			},
+			Severity: severity,
+			Source:   "interp",
+			Message:  diagnostic.Message,
+		})
+	}
+	writeMessage(s.out, map[string]any{
+		"method": "textDocument/publishDiagnostics",
+		"params": map[string]any{"uri": uri, "diagnostics": items},
+	})
+}
+
+func (s *Server) definition(params textDocumentPositionParams) any {
+	document, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	return locations(params.TextDocument.URI, document, document.definitions(params.Position))
+}
+
+func (s *Server) references(params referenceParams) any {
+	document, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return []Location{}
+	}
+	return locations(params.TextDocument.URI, document, document.references(params.Position, params.Context.IncludeDeclaration))
+}
+
+func locations(uri string, document *document, names []token.Token) []Location {
+	locations := []Location{}
+	for _, name := range names {
+		locations = append(locations, Location{uri, document.tokenRange(name)})
+	}
+	return locations
+}
+
+func (s *Server) hover(params textDocumentPositionParams) any {
+	document, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	text, name, ok := document.hover(params.Position, s.builtins)
+	if !ok {
+		return nil
+	}
+	return Hover{MarkupContent{"markdown", "```interp\n" + text + "\n```"}, document.tokenRange(name)}
+}
+
+// completion offers the keywords, the builtins and the names declared in the
+// document. After a dot, it offers the properties and methods instead.
+func (s *Server) completion(params textDocumentPositionParams) any {
+	document, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return []CompletionItem{}
+	}
+
+	items := []CompletionItem{}
+	if afterDot(document.source, Position{params.Position.Line, document.column(params.Position)}) {
+		for _, name := range document.propertyNames() {
+			items = append(items, CompletionItem{Label: name, Kind: completionProperty})
+		}
+		return items
+	}
+
+	for keyword := range token.Keywords {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+	for name, arity := range s.builtins {
+		items = append(items, CompletionItem{Label: name, Kind: completionFunction, Detail: nativeSignature(name, arity)})
+	}
+	for name, kind := range document.names() {
+		if _, ok := s.builtins[name]; !ok {
+			items = append(items, CompletionItem{Label: name, Kind: kind})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+// afterDot reports whether the position, whose character is a column in
+// code points, follows a dot and the start of a name.
+func afterDot(source string, position Position) bool {
+	lines := strings.Split(source, "\n")
+	if position.Line >= len(lines) {
+		return false
+	}
+	runes := []rune(lines[position.Line])
+	line := string(runes[:min(position.Character, len(runes))])
+	line = strings.TrimRightFunc(line, func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)
+	})
+	return strings.HasSuffix(line, ".")
+}
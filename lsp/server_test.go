@@ -0,0 +1,466 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const timeout = 5 * time.Second
+
+const uri = "file:///program.g"
+
+const program = `class Point {
+  init(x, y) { this.x = x; this.y = y; }
+  scale(k) { return Point(this.x * k, this.y * k); }
+}
+fun twice(p) {
+  var result = p.scale(2);
+  return result;
+}
+print twice(Point(1, 2)).x;
+print sqrt(4);
+`
+
+// client drives a server the way an editor would.
+type client struct {
+	t        *testing.T
+	in       io.WriteCloser
+	id       int
+	messages chan map[string]any
+	// notifications are the notifications received while waiting for a
+	// response.
+	notifications []map[string]any
+	served        chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, in: clientOut, messages: make(chan map[string]any, 100), served: make(chan error, 1)}
+	go func() {
+		c.served <- NewServer(serverIn, serverOut).Serve()
+		_ = serverOut.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientIn)
+		for {
+			content, err := readMessage(reader)
+			if err != nil {
+				return
+			}
+			var message map[string]any
+			if err := json.Unmarshal(content, &message); err != nil {
+				t.Errorf("invalid message %s", content)
+				return
+			}
+			c.messages <- message
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = c.in.Close()
+	})
+	return c
+}
+
+func (c *client) send(message map[string]any) {
+	c.t.Helper()
+	message["jsonrpc"] = "2.0"
+	content, _ := json.Marshal(message)
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(content), content); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) next() map[string]any {
+	c.t.Helper()
+	select {
+	case message, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("server closed the connection")
+		}
+		return message
+	case <-time.After(timeout):
+		c.t.Fatal("timed out waiting for a message")
+	}
+	return nil
+}
+
+// request sends a request and returns its response.
+func (c *client) request(method string, params any) map[string]any {
+	c.t.Helper()
+	c.id++
+	c.send(map[string]any{"id": c.id, "method": method, "params": params})
+
+	for {
+		message := c.next()
+		if _, ok := message["id"]; !ok {
+			c.notifications = append(c.notifications, message)
+			continue
+		}
+		if message["id"] != float64(c.id) {
+			c.t.Fatalf("unexpected response %v to %s", message, method)
+		}
+		return message
+	}
+}
+
+// result sends a request that must succeed and returns its result.
+func (c *client) result(method string, params any) any {
+	c.t.Helper()
+	response := c.request(method, params)
+	if response["error"] != nil {
+		c.t.Fatalf("%s failed: %v", method, response["error"])
+	}
+	return response["result"]
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	c.send(map[string]any{"method": method, "params": params})
+}
+
+// diagnostics waits for the next diagnostics published.
+func (c *client) diagnostics() []map[string]any {
+	c.t.Helper()
+	for {
+		var message map[string]any
+		if len(c.notifications) > 0 {
+			message, c.notifications = c.notifications[0], c.notifications[1:]
+		} else {
+			message = c.next()
+		}
+		if message["method"] != "textDocument/publishDiagnostics" {
+			continue
+		}
+		params := message["params"].(map[string]any)
+		if params["uri"] != uri {
+			c.t.Fatalf("diagnostics for %v, want %s", params["uri"], uri)
+		}
+		var diagnostics []map[string]any
+		for _, diagnostic := range params["diagnostics"].([]any) {
+			diagnostics = append(diagnostics, diagnostic.(map[string]any))
+		}
+		return diagnostics
+	}
+}
+
+// open initialises the server and opens a document.
+func (c *client) open(text string) {
+	c.t.Helper()
+	c.result("initialize", map[string]any{"capabilities": map[string]any{}})
+	c.notify("initialized", map[string]any{})
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "interp", "version": 1, "text": text},
+	})
+	c.diagnostics()
+}
+
+func (c *client) change(text string) {
+	c.t.Helper()
+	c.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []any{map[string]any{"text": text}},
+	})
+}
+
+func (c *client) at(method string, line int, character int) any {
+	c.t.Helper()
+	return c.result(method, map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	})
+}
+
+// positions returns where the locations start, as "line:character".
+func positions(locations any) []string {
+	var positions []string
+	for _, location := range locations.([]any) {
+		positions = append(positions, start(location.(map[string]any)["range"]))
+	}
+	return positions
+}
+
+func start(r any) string {
+	start := r.(map[string]any)["start"].(map[string]any)
+	return fmt.Sprintf("%v:%v", start["line"], start["character"])
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	c.result("initialize", map[string]any{})
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "text": "fun f() {\n  var unused = 1;\n  return 2;\n  print 3;\n}\nf();\n"},
+	})
+
+	diagnostics := c.diagnostics()
+	if len(diagnostics) != 2 {
+		t.Fatalf("got diagnostics %v, want two warnings", diagnostics)
+	}
+	if diagnostics[0]["severity"] != float64(severityWarning) || start(diagnostics[0]["range"]) != "1:6" ||
+		diagnostics[0]["message"] != "Variable 'unused' is declared but never used." {
+		t.Fatalf("unexpected diagnostic %v", diagnostics[0])
+	}
+	if diagnostics[1]["severity"] != float64(severityWarning) || start(diagnostics[1]["range"]) != "2:2" ||
+		diagnostics[1]["message"] != "Unreachable code after return." {
+		t.Fatalf("unexpected diagnostic %v", diagnostics[1])
+	}
+
+	c.change("var = 1;\n")
+	diagnostics = c.diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0]["severity"] != float64(severityError) || start(diagnostics[0]["range"]) != "0:0" {
+		t.Fatalf("got diagnostics %v, want a syntax error", diagnostics)
+	}
+
+	c.notify("textDocument/didClose", map[string]any{"textDocument": map[string]any{"uri": uri}})
+	if diagnostics := c.diagnostics(); len(diagnostics) != 0 {
+		t.Fatalf("got diagnostics %v after closing", diagnostics)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t)
+	c.open(program)
+
+	tests := []struct {
+		line, character int
+		want            string
+	}{
+		{6, 10, "5:6"},  // a local
+		{8, 7, "4:4"},   // a global function
+		{2, 21, "0:6"},  // a class used inside its own method
+		{5, 15, "4:10"}, // a parameter
+		{5, 18, "2:2"},  // a method
+	}
+	for _, test := range tests {
+		got := positions(c.at("textDocument/definition", test.line, test.character))
+		if len(got) != 1 || got[0] != test.want {
+			t.Errorf("definition at %d:%d is %v, want %s", test.line, test.character, got, test.want)
+		}
+	}
+
+	if got := c.at("textDocument/definition", 9, 7); len(got.([]any)) != 0 {
+		t.Errorf("a builtin has definitions %v", got)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	c := newClient(t)
+	c.open(program)
+
+	references := func(line int, character int, includeDeclaration bool) string {
+		return strings.Join(positions(c.result("textDocument/references", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     map[string]any{"line": line, "character": character},
+			"context":      map[string]any{"includeDeclaration": includeDeclaration},
+		})), " ")
+	}
+
+	if got := references(0, 7, true); got != "0:6 2:20 8:12" {
+		t.Errorf("references to Point are %s", got)
+	}
+	if got := references(4, 10, false); got != "5:15" {
+		t.Errorf("references to p are %s", got)
+	}
+	if got := references(8, 25, false); got != "1:20 2:31 8:25" {
+		t.Errorf("references to the x property are %s", got)
+	}
+	if got := references(2, 3, true); got != "2:2 5:17" {
+		t.Errorf("references to scale are %s", got)
+	}
+}
+
+func TestPositionEncoding(t *testing.T) {
+	// The emoji is one code point, but two UTF-16 code units.
+	const text = "var s = \"😀\"; print s;\nprint \"😀\" + ;\n"
+	tests := []struct {
+		encodings []string
+		utf32     bool
+		// use is where the second s is, and want its references and the
+		// start and end of the syntax error.
+		use, want, syntaxError string
+	}{
+		{nil, false, "0:20", "0:4 0:20", "1:13-1:14"},
+		{[]string{"utf-16"}, false, "0:20", "0:4 0:20", "1:13-1:14"},
+		{[]string{"utf-16", "utf-32"}, true, "0:19", "0:4 0:19", "1:12-1:13"},
+	}
+
+	for _, test := range tests {
+		c := newClient(t)
+		initialize := c.result("initialize", map[string]any{
+			"capabilities": map[string]any{"general": map[string]any{"positionEncodings": test.encodings}},
+		})
+		encoding := initialize.(map[string]any)["capabilities"].(map[string]any)["positionEncoding"]
+		if test.utf32 != (encoding == "utf-32") {
+			t.Errorf("%v: position encoding is %v", test.encodings, encoding)
+		}
+		c.notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{"uri": uri, "text": text},
+		})
+
+		diagnostics := c.diagnostics()
+		if len(diagnostics) != 1 {
+			t.Fatalf("%v: got diagnostics %v, want a syntax error", test.encodings, diagnostics)
+		}
+		r := diagnostics[0]["range"].(map[string]any)
+		end := r["end"].(map[string]any)
+		if got := fmt.Sprintf("%s-%v:%v", start(r), end["line"], end["character"]); got != test.syntaxError {
+			t.Errorf("%v: syntax error is at %s, want %s", test.encodings, got, test.syntaxError)
+		}
+
+		var line, character int
+		_, _ = fmt.Sscanf(test.use, "%d:%d", &line, &character)
+		got := strings.Join(positions(c.result("textDocument/references", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     map[string]any{"line": line, "character": character},
+			"context":      map[string]any{"includeDeclaration": true},
+		})), " ")
+		if got != test.want {
+			t.Errorf("%v: references are %s, want %s", test.encodings, got, test.want)
+		}
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	c := newClient(t)
+	c.open(program)
+
+	var got []string
+	for _, symbol := range c.result("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": uri}}).([]any) {
+		symbol := symbol.(map[string]any)
+		description := fmt.Sprintf("%v %v", symbol["kind"], symbol["name"])
+		children, _ := symbol["children"].([]any)
+		for _, child := range children {
+			child := child.(map[string]any)
+			description += fmt.Sprintf(" (%v %v%v)", child["kind"], child["name"], child["detail"])
+		}
+		got = append(got, description)
+	}
+
+	want := []string{"5 Point (6 init(x, y)) (6 scale(k))", "12 twice"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("got symbols %v, want %v", got, want)
+	}
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	c.open(program)
+
+	tests := []struct {
+		line, character int
+		want            string
+	}{
+		{8, 7, "fun twice(p)"},
+		{0, 7, "class Point\nPoint(x, y)"},
+		{5, 19, "method Point.scale(k)"},
+		{5, 15, "parameter p of fun twice(p)"},
+		{9, 6, "native fun sqrt(_)"},
+	}
+	for _, test := range tests {
+		hover, _ := c.at("textDocument/hover", test.line, test.character).(map[string]any)
+		if hover == nil {
+			t.Errorf("no hover at %d:%d", test.line, test.character)
+			continue
+		}
+		got := hover["contents"].(map[string]any)["value"]
+		if want := "```interp\n" + test.want + "\n```"; got != want {
+			t.Errorf("hover at %d:%d is %q, want %q", test.line, test.character, got, want)
+		}
+	}
+
+	if hover := c.at("textDocument/hover", 3, 0); hover != nil {
+		t.Errorf("got hover %v on a brace", hover)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.open(program)
+
+	labels := func(line int, character int) map[string]float64 {
+		labels := map[string]float64{}
+		for _, item := range c.at("textDocument/completion", line, character).([]any) {
+			item := item.(map[string]any)
+			labels[item["label"].(string)] = item["kind"].(float64)
+		}
+		return labels
+	}
+
+	got := labels(10, 0)
+	want := map[string]float64{
+		"while":  completionKeyword,
+		"sqrt":   completionFunction,
+		"twice":  completionFunction,
+		"Point":  completionClass,
+		"result": completionVariable,
+	}
+	for label, kind := range want {
+		if got[label] != kind {
+			t.Errorf("completion %s has kind %v, want %v", label, got[label], kind)
+		}
+	}
+	if _, ok := got["scale"]; ok {
+		t.Errorf("methods are completed outside of property accesses")
+	}
+
+	// The document doesn't parse while the property is being typed, so the
+	// names come from the last version that did.
+	c.change(program + "twice(Point(1, 2)).sc")
+	c.diagnostics()
+	got = labels(10, 21)
+	for _, label := range []string{"scale", "x", "y"} {
+		if got[label] != completionProperty {
+			t.Errorf("property %s has kind %v", label, got[label])
+		}
+	}
+	if _, ok := got["while"]; ok {
+		t.Errorf("keywords are completed in property accesses")
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newClient(t)
+	if response := c.request("textDocument/hover", map[string]any{}); response["error"].(map[string]any)["code"] != float64(codeServerNotInitialized) {
+		t.Fatalf("unexpected response %v before initialize", response)
+	}
+
+	c.result("initialize", map[string]any{})
+	if response := c.request("workspace/symbol", map[string]any{}); response["error"].(map[string]any)["code"] != float64(codeMethodNotFound) {
+		t.Fatalf("unexpected response %v to an unknown method", response)
+	}
+
+	if result := c.result("shutdown", nil); result != nil {
+		t.Fatalf("shutdown returned %v", result)
+	}
+	c.notify("exit", nil)
+	select {
+	case err := <-c.served:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(timeout):
+		t.Fatal("server didn't stop after exit")
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newClient(t)
+	c.result("initialize", map[string]any{})
+	c.notify("exit", nil)
+	select {
+	case err := <-c.served:
+		if err != ErrExit {
+			t.Fatalf("got %v, want ErrExit", err)
+		}
+	case <-time.After(timeout):
+		t.Fatal("server didn't stop after exit")
+	}
+}
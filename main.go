@@ -1,55 +1,338 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"github.com/samber/lo"
+	"interp/ast"
+	"interp/bytecode"
+	"interp/dap"
 	"interp/errors"
 	"interp/interpreter"
+	"interp/lsp"
 	"interp/parser"
 	"interp/resolver"
 	"interp/scanner"
+	"io"
 	"os"
+	"path/filepath"
 )
 
-//goland:noinspection GoTypeAssertionOnErrors,GoTypeAssertionOnErrors
+// Exit codes returned by the interpreter.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 64
+	exitSyntax  = 65
+	exitResolve = 66
+	exitRuntime = 70
+	exitIO      = 74
+)
+
+const usage = `Usage:
+  interp                        start an interactive session
+  interp <script> [args...]     run a script file
+  interp - [args...]            read the script from stdin
+  interp -e <source> [args...]  run the given source
+  interp debug <script> [args...]
+                                run a script in the step debugger
+  interp dap                    serve the Debug Adapter Protocol on
+                                stdin and stdout
+  interp lsp                    serve the Language Server Protocol on
+                                stdin and stdout
+  interp fmt [-w | -check] [paths...]
+                                format scripts, and the .g files in
+                                directories; without paths, format
+                                stdin to stdout. -w rewrites the files,
+                                -check lists those not formatted
+  interp ast [-json] <script>   print the syntax tree of a script as
+                                S-expressions, or as JSON
+  interp test [-run <regexp>] [-json | -junit] [-path <directories>] [paths...]
+                                run the test functions, those named
+                                test*, of the _test.g files given or
+                                in the directories given; -run selects
+                                tests by name, -json and -junit print
+                                the results as JSON or JUnit XML, and
+                                -path is where imported modules are
+                                looked for, as for scripts
+
+Options:
+  -diagnostics <format>         how errors and warnings are shown:
+                                pretty (default), text or json
+  -backend <backend>            how scripts are run: tree (default)
+                                walks the syntax tree, vm compiles
+                                them to bytecode
+  -path <directories>           where imported modules are looked for
+                                when they aren't next to the importing
+                                script, separated like $PATH; defaults
+                                to $INTERP_PATH
+
+Exit codes:
+  0   success
+  1   interp fmt -check found scripts that aren't formatted, or
+      interp test had failing tests
+  64  invalid usage
+  65  syntax error
+  66  resolve error
+  70  runtime error
+  74  failed to read the script
+`
+
 func main() {
-	path := "test.g"
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "debug":
+			os.Exit(debug(os.Args[2:]))
+		case "dap":
+			if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(exitIO)
+			}
+			os.Exit(exitOK)
+		case "ast":
+			os.Exit(printTree(os.Args[2:]))
+		case "fmt":
+			os.Exit(formatScripts(os.Args[2:]))
+		case "test":
+			os.Exit(runTests(os.Args[2:]))
+		case "lsp":
+			err := lsp.NewServer(os.Stdin, os.Stdout).Serve()
+			if err == lsp.ErrExit {
+				// The protocol asks for 1 when the client didn't shut the
+				// server down first.
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(exitIO)
+			}
+			os.Exit(exitOK)
+		}
+	}
 
-	bytes, err := os.ReadFile(path)
-	if err != nil {
-		fmt.Println(err)
-		return
+	flags := flag.NewFlagSet("interp", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
 	}
-	source := string(bytes)
+	expression := flags.String("e", "", "run the given source")
+	format := flags.String("diagnostics", formatPretty, "how errors and warnings are shown")
+	backend := flags.String("backend", backendTree, "how scripts are run")
+	searchPath := flags.String("path", os.Getenv("INTERP_PATH"), "where imported modules are looked for")
 
-	scan := scanner.NewScanner(source)
-	tokens, err := scan.ScanTokens()
-	if err != nil {
-		fmt.Println(err)
-		return
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(exitUsage)
+	}
+	args := flags.Args()
+
+	switch *format {
+	case formatPretty, formatText, formatJSON:
+	default:
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	switch *backend {
+	case backendTree, backendVM:
+	default:
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	options := options{
+		format:     *format,
+		backend:    *backend,
+		searchPath: filepath.SplitList(*searchPath),
 	}
 
-	par := parser.NewParser(tokens)
-	statements, err := par.Parse()
-	if err, ok := err.(errors.SyntaxError); ok {
-		err.Print(&source)
+	var source, file string
+	switch {
+	case isFlagSet(flags, "e"):
+		source = *expression
+		file = fileCommandLine
+	case len(args) == 0:
+		os.Exit(repl(os.Stdin, options))
+	case args[0] == "-":
+		bytes, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(exitIO)
+		}
+		source = string(bytes)
+		file = fileStdin
+		args = args[1:]
+	default:
+		bytes, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(exitIO)
+		}
+		source = string(bytes)
+		file = args[0]
+		args = args[1:]
 	}
-	if errors.HadError {
-		return
+
+	os.Exit(run(source, file, args, options))
+}
+
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Backends that can run scripts.
+const (
+	backendTree = "tree"
+	backendVM   = "vm"
+)
+
+// Names of scripts that aren't read from a file.
+const (
+	fileCommandLine = "<command line>"
+	fileStdin       = "<stdin>"
+)
+
+// options are the command line options that affect how scripts are run.
+type options struct {
+	format     string
+	backend    string
+	searchPath []string
+}
+
+func run(source string, file string, args []string, options options) int {
+	format := options.format
+	diagnostics := errors.NewDiagnostics(file)
+
+	statements := parse(source, diagnostics)
+	if diagnostics.HasErrors() {
+		report(diagnostics, source, format)
+		return exitSyntax
+	}
+
+	if options.backend == backendVM {
+		return runVM(statements, diagnostics, source, file, args, options)
 	}
 
 	inter := interpreter.NewInterpreter()
+	inter.SetFile(file)
+	inter.SetSearchPath(options.searchPath)
+	inter.Define("args", interpreter.NewList(lo.ToAnySlice(args)))
 
-	res := resolver.NewResolver(&inter)
+	res := resolver.NewResolver(&inter, diagnostics)
 	if err := res.Resolve(statements); err != nil {
 		fmt.Println(err)
 	}
 
-	if errors.HadError {
-		return
+	report(diagnostics, source, format)
+	if diagnostics.HasErrors() {
+		return exitResolve
 	}
 
-	err = inter.Interpret(statements)
-	if err, ok := err.(errors.RuntimeError); ok {
-		err.Print(&source)
+	return reportRuntime(inter.Interpret(statements), source, file, format)
+}
+
+// parse scans and parses the source. Errors are reported to the diagnostics.
+func parse(source string, diagnostics *errors.Diagnostics) []ast.Stmt {
+	scan := scanner.NewScanner(source, diagnostics)
+	tokens, _ := scan.ScanTokens()
+	if diagnostics.HasErrors() {
+		return nil
+	}
+
+	par := parser.NewParser(tokens, diagnostics)
+	statements, _ := par.Parse()
+	return statements
+}
+
+// runVM runs the parsed script on the bytecode VM. The resolver still checks
+// the script, but the compiler resolves variables itself.
+func runVM(statements []ast.Stmt, diagnostics *errors.Diagnostics, source string, file string, args []string, options options) int {
+	format := options.format
+	res := resolver.NewResolver(discardLocals{}, diagnostics)
+	if err := res.Resolve(statements); err != nil {
+		fmt.Println(err)
+	}
+	if diagnostics.HasErrors() {
+		report(diagnostics, source, format)
+		return exitResolve
+	}
+
+	function := bytecode.Compile(statements, diagnostics)
+	report(diagnostics, source, format)
+	if diagnostics.HasErrors() {
+		return exitResolve
+	}
+
+	vm := bytecode.NewVM()
+	vm.SetFile(file)
+	vm.SetSearchPath(options.searchPath)
+	vm.Define("args", interpreter.NewList(lo.ToAnySlice(args)))
+	return reportRuntime(vm.Run(function), source, file, format)
+}
+
+type discardLocals struct{}
+
+func (discardLocals) Resolve(ast.Expr, int, int) {}
+
+//goland:noinspection GoTypeAssertionOnErrors
+func reportRuntime(err error, source string, file string, format string) int {
+	if err != nil {
+		runtimeErr, ok := err.(errors.RuntimeError)
+		switch {
+		case format != formatPretty:
+			report(runtimeDiagnostics(err, file), source, format)
+		case ok:
+			runtimeErr.Print(&source)
+		default:
+			fmt.Println(err)
+		}
+		return exitRuntime
+	}
+
+	return exitOK
+}
+
+// runtimeDiagnostics returns the runtime error as a diagnostic, in the file
+// of the function it occurred in. Errors without a token are put at the start
+// of the script.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func runtimeDiagnostics(err error, file string) *errors.Diagnostics {
+	runtimeErr, ok := err.(errors.RuntimeError)
+	if !ok {
+		diagnostics := errors.NewDiagnostics(file)
+		diagnostics.ErrorAt(1, 0, 1, err.Error())
+		return diagnostics
+	}
+
+	if stack := runtimeErr.Stack(); len(stack) > 0 && stack[0].File != "" {
+		file = stack[0].File
+	}
+	diagnostics := errors.NewDiagnostics(file)
+	diagnostics.Error(runtimeErr.Token(), runtimeErr.Message())
+	return diagnostics
+}
+
+// Formats in which diagnostics can be shown.
+const (
+	formatPretty = "pretty"
+	formatText   = "text"
+	formatJSON   = "json"
+)
+
+func report(diagnostics *errors.Diagnostics, source string, format string) {
+	switch format {
+	case formatText:
+		diagnostics.Text(os.Stdout)
+	case formatJSON:
+		if len(diagnostics.Items()) > 0 {
+			_ = diagnostics.JSON(os.Stdout)
+		}
+	default:
+		diagnostics.Print(os.Stdout, source)
 	}
 }
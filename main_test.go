@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"interp/errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The conformance tests run every .g file under testdata through the
+// interpreter, with both backends. Scripts say what they should do in
+// comments:
+//
+//	print 1 + 2; // expect: 3
+//	print nil.x; // expect runtime error: Only instances have properties.
+//	return 1;    // expect error: Can't return from top-level code.
+//	{ var a; }   // expect warning: Variable 'a' is declared but never used.
+//
+// Output is expected in the order of the comments. Errors, runtime errors
+// among them, and warnings are expected on the line of their comment.
+//
+// Modules that scripts import are kept in lib directories, which aren't run
+// themselves.
+
+// runMainEnv makes the test binary run main instead of the tests, so that
+// scripts go through the same pipeline as with the interp command.
+const runMainEnv = "INTERP_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(exitOK)
+	}
+	os.Exit(m.Run())
+}
+
+var (
+	expectOutput       = regexp.MustCompile(`// expect: (.*)$`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.*)$`)
+	expectDiagnostic   = regexp.MustCompile(`// expect (error|warning): (.*)$`)
+	diagnosticLine     = regexp.MustCompile(`^.*:(\d+):\d+: (error|warning): (.*)$`)
+)
+
+// expectations is what a script should print and exit with.
+type expectations struct {
+	output      []string
+	diagnostics []string
+	status      []int
+}
+
+func TestScripts(t *testing.T) {
+	var files []string
+	err := filepath.WalkDir("testdata", func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() && entry.Name() == "lib" {
+			return filepath.SkipDir
+		}
+		if err == nil && !entry.IsDir() && filepath.Ext(path) == ".g" {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no scripts in testdata")
+	}
+
+	for _, file := range files {
+		want, err := parseExpectations(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, backend := range []string{"tree", "vm"} {
+			name := strings.TrimSuffix(filepath.ToSlash(strings.TrimPrefix(file, "testdata"+string(filepath.Separator))), ".g")
+			t.Run(name+"/"+backend, func(t *testing.T) {
+				runScript(t, file, backend, want)
+			})
+		}
+	}
+}
+
+// parseExpectations reads the expect comments of a script.
+func parseExpectations(file string) (expectations, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return expectations{}, err
+	}
+
+	var want expectations
+	hasErrors := false
+	runtimeError := false
+	scanner := bufio.NewScanner(bytes.NewReader(source))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if match := expectOutput.FindStringSubmatch(text); match != nil {
+			want.output = append(want.output, match[1])
+		} else if match := expectRuntimeError.FindStringSubmatch(text); match != nil {
+			want.diagnostics = append(want.diagnostics, fmt.Sprintf("%d: error: %s", line, match[1]))
+			runtimeError = true
+		} else if match := expectDiagnostic.FindStringSubmatch(text); match != nil {
+			want.diagnostics = append(want.diagnostics, fmt.Sprintf("%d: %s: %s", line, match[1], match[2]))
+			hasErrors = hasErrors || match[1] == "error"
+		}
+	}
+
+	switch {
+	case hasErrors && runtimeError:
+		return expectations{}, fmt.Errorf("%s: expects both errors and a runtime error", file)
+	case hasErrors:
+		want.status = []int{exitSyntax, exitResolve}
+	case runtimeError:
+		want.status = []int{exitRuntime}
+	default:
+		want.status = []int{exitOK}
+	}
+	return want, scanner.Err()
+}
+
+// runScript runs a script with the interp command and checks what it
+// printed against the expectations.
+func runScript(t *testing.T, file string, backend string, want expectations) {
+	cmd := exec.Command(os.Args[0], "-diagnostics", "text", "-backend", backend, file)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	stdout, err := cmd.Output()
+
+	status := exitOK
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		status = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	var output, diagnostics []string
+	if len(stdout) > 0 {
+		for _, line := range strings.Split(strings.TrimSuffix(string(stdout), "\n"), "\n") {
+			if match := diagnosticLine.FindStringSubmatch(line); match != nil {
+				diagnostics = append(diagnostics, fmt.Sprintf("%s: %s: %s", match[1], match[2], match[3]))
+			} else {
+				output = append(output, line)
+			}
+		}
+	}
+
+	if !slices.Contains(want.status, status) {
+		t.Errorf("exit status %d, want %s", status, statuses(want.status))
+	}
+	if !slices.Equal(output, want.output) {
+		t.Errorf("output\n%s\nwant\n%s", lines(output), lines(want.output))
+	}
+	if !slices.Equal(diagnostics, want.diagnostics) {
+		t.Errorf("diagnostics\n%s\nwant\n%s", lines(diagnostics), lines(want.diagnostics))
+	}
+}
+
+// TestRuntimeErrorJSON checks that runtime errors are reported in the same
+// JSON shape as other diagnostics.
+func TestRuntimeErrorJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fail.g")
+	if err := os.WriteFile(file, []byte("print \"before\";\nprint nil + 1;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := errors.Diagnostic{
+		Severity: errors.SeverityError,
+		File:     file,
+		Line:     2,
+		Column:   11,
+		Span:     1,
+		Message:  "Operands must be two numbers or two strings.",
+	}
+	for _, backend := range []string{"tree", "vm"} {
+		cmd := exec.Command(os.Args[0], "-diagnostics", "json", "-backend", backend, file)
+		cmd.Env = append(os.Environ(), runMainEnv+"=1")
+		stdout, err := cmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != exitRuntime {
+			t.Errorf("%s: exit error %v, want status %d", backend, err, exitRuntime)
+		}
+
+		output, report, _ := strings.Cut(string(stdout), "\n")
+		var diagnostics []errors.Diagnostic
+		if err := json.Unmarshal([]byte(report), &diagnostics); err != nil {
+			t.Errorf("%s: %v in\n%s", backend, err, stdout)
+			continue
+		}
+		if output != "before" || len(diagnostics) != 1 || diagnostics[0] != want {
+			t.Errorf("%s: printed %q and %+v, want %+v", backend, output, diagnostics, want)
+		}
+	}
+}
+
+// TestREPL checks that the REPL prints the values of expressions, including
+// map literals, and runs statements, waiting for the ones not finished yet.
+func TestREPL(t *testing.T) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	cmd.Stdin = strings.NewReader("{\"a\": 1}\nvar x = 2;\nx * 3\nif (x > 1) {\n  print \"big\";\n}\n{ print \"block\"; }\n")
+	stdout, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := strings.NewReplacer(continuationPrompt, "", prompt, "").Replace(string(stdout))
+	want := "{\"a\": 1}\n6\nbig\nblock\n\n"
+	if output != want {
+		t.Errorf("printed %q, want %q", output, want)
+	}
+}
+
+func statuses(status []int) string {
+	text := make([]string, len(status))
+	for i, s := range status {
+		text[i] = strconv.Itoa(s)
+	}
+	return strings.Join(text, " or ")
+}
+
+func lines(lines []string) string {
+	if len(lines) == 0 {
+		return "\t(nothing)"
+	}
+	return "\t" + strings.Join(lines, "\n\t")
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"interp/ast"
+	. "interp/token"
+)
+
+// SetComments makes the parser attach the comments, as returned by
+// Scanner.Comments, to the statements it parses.
+func (p *Parser) SetComments(comments []Token) {
+	p.pending = comments
+	p.comments = ast.NewComments()
+}
+
+// Comments returns the comments attached by Parse, or nil when SetComments
+// wasn't called.
+func (p *Parser) Comments() *ast.Comments {
+	return p.comments
+}
+
+// leading are the comments before a statement and whether a blank line
+// separates them from what comes before.
+type leading struct {
+	comments []Token
+	spaced   bool
+}
+
+// leadingComments takes the comments before the statement about to be
+// parsed.
+func (p *Parser) leadingComments() leading {
+	if p.comments == nil {
+		return leading{}
+	}
+
+	comments := p.takeComments(p.peek())
+	first := p.peek()
+	if len(comments) > 0 {
+		first = comments[0]
+	}
+	return leading{comments, p.current > 0 && first.Line > p.previous().Line+1}
+}
+
+// attachComments attaches the leading comments to the statement that has
+// just been parsed, along with the comments left inside it and after it on
+// its last line.
+func (p *Parser) attachComments(stmt ast.Stmt, leading leading) {
+	if p.comments == nil {
+		return
+	}
+
+	if len(leading.comments) > 0 {
+		p.comments.Leading[stmt] = leading.comments
+	}
+	if leading.spaced {
+		p.comments.Spaced[stmt] = true
+	}
+
+	end := p.previous().Line
+	var trailing []Token
+	for len(p.pending) > 0 && before(p.pending[0], p.peek()) && p.pending[0].Line <= end {
+		trailing = append(trailing, p.pending[0])
+		p.pending = p.pending[1:]
+	}
+	if len(trailing) > 0 {
+		p.comments.Trailing[stmt] = trailing
+	}
+}
+
+// endComments takes the comments before the '}' that closes a body; the
+// node the body belongs to gets them with attachEnd.
+func (p *Parser) endComments() {
+	if p.comments != nil {
+		p.end = p.takeComments(p.peek())
+		p.endSpaced = len(p.end) > 0 && p.current > 0 && p.end[0].Line > p.previous().Line+1
+	}
+}
+
+// attachEnd attaches the comments at the end of the body that has just been
+// parsed to the node it belongs to.
+func (p *Parser) attachEnd(node any) {
+	if len(p.end) > 0 {
+		p.comments.End[node] = p.end
+	}
+	if p.endSpaced {
+		p.comments.EndSpaced[node] = true
+	}
+	p.end, p.endSpaced = nil, false
+}
+
+// takeComments takes the pending comments before the token.
+func (p *Parser) takeComments(token Token) []Token {
+	var comments []Token
+	for len(p.pending) > 0 && (token.Type == EOF || before(p.pending[0], token)) {
+		comments = append(comments, p.pending[0])
+		p.pending = p.pending[1:]
+	}
+	return comments
+}
+
+func before(comment Token, token Token) bool {
+	return comment.Line < token.Line || comment.Line == token.Line && comment.Column < token.Column
+}
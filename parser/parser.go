@@ -9,27 +9,57 @@ import (
 )
 
 type Parser struct {
-	tokens  []Token
-	current int
+	tokens      []Token
+	current     int
+	diagnostics *errors.Diagnostics
+	errors      errors.SyntaxErrors
+
+	// comments are attached to the statements when SetComments was called.
+	// pending are those not attached yet, and end those at the end of the
+	// body that has just been parsed, with endSpaced set when a blank line
+	// comes before them.
+	comments  *ast.Comments
+	pending   []Token
+	end       []Token
+	endSpaced bool
 }
 
-func NewParser(tokens []Token) Parser {
-	return Parser{tokens: tokens}
+func NewParser(tokens []Token, diagnostics *errors.Diagnostics) Parser {
+	return Parser{tokens: tokens, diagnostics: diagnostics}
 }
 
+// Parse parses the whole file. It doesn't stop at syntax errors; instead it
+// skips to the next statement and keeps going. The statements that could be
+// parsed are returned along with every error found, as errors.SyntaxErrors.
 func (p *Parser) Parse() ([]ast.Stmt, error) {
 	var statements []ast.Stmt
 	for !p.isAtEnd() {
 		declaration, err := p.declaration()
 		if err != nil {
-			return nil, err
+			continue
 		}
 
 		statements = append(statements, declaration)
 	}
+	p.endComments()
+	p.attachEnd(nil)
+	if len(p.errors) > 0 {
+		return statements, p.errors
+	}
 	return statements, nil
 }
 
+func (p *Parser) ParseExpression() (ast.Expr, error) {
+	exp, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isAtEnd() {
+		return nil, p.error(p.peek(), "Expect end of expression.")
+	}
+	return exp, nil
+}
+
 func (p *Parser) expression() (ast.Expr, error) {
 	return p.assigment()
 }
@@ -39,6 +69,7 @@ func (p *Parser) declaration() (ast.Stmt, error) {
 		statement ast.Stmt
 		err       error
 	)
+	leading := p.leadingComments()
 	switch {
 	case p.match(Class):
 		statement, err = p.classDeclaration()
@@ -46,6 +77,10 @@ func (p *Parser) declaration() (ast.Stmt, error) {
 		statement, err = p.function("function")
 	case p.match(Var):
 		statement, err = p.varDeclaration()
+	case p.match(Import):
+		statement, err = p.importDeclaration()
+	case p.match(Export):
+		statement, err = p.exportDeclaration()
 	default:
 		statement, err = p.statement()
 	}
@@ -53,14 +88,101 @@ func (p *Parser) declaration() (ast.Stmt, error) {
 		p.synchronize()
 		return nil, err
 	}
+	p.attachComments(statement, leading)
 	return statement, nil
 }
 
+func (p *Parser) importDeclaration() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	var (
+		alias *Token
+		names []Token
+	)
+	if p.match(LeftBrace) {
+		for {
+			name, err := p.consume(Identifier, "Expect name to import.")
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, *name)
+			if !p.match(Comma) || p.check(RightBrace) {
+				break
+			}
+		}
+		_, err := p.consume(RightBrace, "Expect '}' after imported names.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consumeWord("from", "Expect 'from' after imported names.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	path, err := p.consume(String, "Expect module path.")
+	if err != nil {
+		return nil, err
+	}
+
+	if names == nil {
+		_, err = p.consumeWord("as", "Expect 'as' after module path.")
+		if err != nil {
+			return nil, err
+		}
+		alias, err = p.consume(Identifier, "Expect module name.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(Semicolon, "Expect ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewImportStmt(keyword, *path, alias, names), nil
+}
+
+func (p *Parser) exportDeclaration() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	var (
+		declaration ast.Stmt
+		err         error
+	)
+	switch {
+	case p.match(Class):
+		declaration, err = p.classDeclaration()
+	case p.match(Fun):
+		declaration, err = p.function("function")
+	case p.match(Var):
+		declaration, err = p.varDeclaration()
+	default:
+		return nil, p.error(p.previous(), "Expect declaration after 'export'.")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewExportStmt(keyword, declaration), nil
+}
+
 func (p *Parser) classDeclaration() (ast.Stmt, error) {
 	name, err := p.consume(Identifier, "Expect class name.")
 	if err != nil {
 		return nil, err
 	}
+
+	var superclass *ast.VariableExpr
+	if p.match(Less) {
+		_, err = p.consume(Identifier, "Expect superclass name.")
+		if err != nil {
+			return nil, err
+		}
+		superclass = ast.NewVariableExpr(p.previous())
+	}
+
 	_, err = p.consume(LeftBrace, "Expect '{' before class body.")
 	if err != nil {
 		return nil, err
@@ -68,19 +190,24 @@ func (p *Parser) classDeclaration() (ast.Stmt, error) {
 
 	var methods []*ast.FunctionStmt
 	for !p.check(RightBrace) && !p.isAtEnd() {
+		leading := p.leadingComments()
 		function, err := p.function("method")
 		if err != nil {
 			return nil, err
 		}
+		p.attachComments(function, leading)
 		methods = append(methods, function)
 	}
 
+	p.endComments()
 	_, err = p.consume(RightBrace, "Expect '}' after class body.")
 	if err != nil {
 		return nil, err
 	}
 
-	return ast.NewClassStmt(*name, methods), nil
+	class := ast.NewClassStmt(*name, superclass, methods)
+	p.attachEnd(class)
+	return class, nil
 }
 
 func (p *Parser) statement() (ast.Stmt, error) {
@@ -97,18 +224,28 @@ func (p *Parser) statement() (ast.Stmt, error) {
 		return p.whileStatement()
 	case p.match(Break):
 		return p.breakStatement()
+	case p.match(Continue):
+		return p.continueStatement()
+	case p.match(Throw):
+		return p.throwStatement()
+	case p.match(Try):
+		return p.tryStatement()
 	case p.match(LeftBrace):
+		brace := p.previous()
 		statements, err := p.block()
 		if err != nil {
 			return nil, err
 		}
-		return ast.NewBlockStmt(statements), nil
+		block := ast.NewBlockStmt(brace, statements)
+		p.attachEnd(block)
+		return block, nil
 	}
 
 	return p.expressionStatement()
 }
 
 func (p *Parser) forStatement() (ast.Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(LeftParen, "Expect '(' after 'for'.")
 	if err != nil {
 		return nil, err
@@ -156,26 +293,20 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	if increment != nil {
-		body = ast.NewBlockStmt([]ast.Stmt{
-			body,
-			ast.NewExpressionStmt(increment),
-		})
-	}
-
 	if condition == nil {
-		condition = ast.NewLiteralExpr(true)
+		condition = ast.NewLiteralExpr(keyword, true)
 	}
-	body = ast.NewWhileStmt(condition, body)
+	body = ast.NewWhileStmt(keyword, condition, body, increment)
 
 	if initializer != nil {
-		body = ast.NewBlockStmt([]ast.Stmt{initializer, body})
+		body = ast.NewBlockStmt(keyword, []ast.Stmt{initializer, body})
 	}
 
 	return body, nil
 }
 
 func (p *Parser) ifStatement() (ast.Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(LeftParen, "Expect '(' after 'if'.")
 	if err != nil {
 		return nil, err
@@ -204,10 +335,11 @@ func (p *Parser) ifStatement() (ast.Stmt, error) {
 		}
 	}
 
-	return ast.NewIfStmt(condition, thenBranch, elseBranch), nil
+	return ast.NewIfStmt(keyword, condition, thenBranch, elseBranch), nil
 }
 
 func (p *Parser) printStatement() (ast.Stmt, error) {
+	keyword := p.previous()
 	value, err := p.expression()
 	if err != nil {
 		return nil, err
@@ -218,7 +350,7 @@ func (p *Parser) printStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return ast.NewPrintStmt(value), nil
+	return ast.NewPrintStmt(keyword, value), nil
 }
 
 func (p *Parser) returnStatement() (ast.Stmt, error) {
@@ -240,6 +372,84 @@ func (p *Parser) returnStatement() (ast.Stmt, error) {
 	return ast.NewReturnStmt(keyword, value), nil
 }
 
+func (p *Parser) throwStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(Semicolon, "Expect ';' after thrown value.")
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewThrowStmt(keyword, value), nil
+}
+
+func (p *Parser) tryStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	body, err := p.blockStatement("try")
+	if err != nil {
+		return nil, err
+	}
+
+	var catch *ast.CatchClause
+	if p.match(Catch) {
+		_, err = p.consume(LeftParen, "Expect '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.consume(Identifier, "Expect variable name.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(RightParen, "Expect ')' after catch variable.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(LeftBrace, "Expect '{' before catch body.")
+		if err != nil {
+			return nil, err
+		}
+		statements, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		catch = ast.NewCatchClause(*name, statements)
+		p.attachEnd(catch)
+	}
+
+	var finally *ast.BlockStmt
+	if p.match(Finally) {
+		finally, err = p.blockStatement("finally")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catch == nil && finally == nil {
+		return nil, p.error(p.previous(), "Expect 'catch' or 'finally' after try block.")
+	}
+
+	return ast.NewTryStmt(keyword, body, catch, finally), nil
+}
+
+// blockStatement parses the block that follows a keyword.
+func (p *Parser) blockStatement(keyword string) (*ast.BlockStmt, error) {
+	brace, err := p.consume(LeftBrace, fmt.Sprintf("Expect '{' after '%s'.", keyword))
+	if err != nil {
+		return nil, err
+	}
+	statements, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	block := ast.NewBlockStmt(*brace, statements)
+	p.attachEnd(block)
+	return block, nil
+}
+
 func (p *Parser) expressionStatement() (ast.Stmt, error) {
 	exp, err := p.expression()
 	if err != nil {
@@ -299,10 +509,13 @@ func (p *Parser) function(kind string) (*ast.FunctionStmt, error) {
 		return nil, err
 	}
 
-	return ast.NewFunctionStmt(*name, parameters, body), nil
+	function := ast.NewFunctionStmt(*name, parameters, body)
+	p.attachEnd(function)
+	return function, nil
 }
 
 func (p *Parser) lambda() (*ast.LambdaExpr, error) {
+	keyword := p.previous()
 	_, err := p.consume(LeftParen, "Expect '(' after 'fun'")
 	if err != nil {
 		return nil, err
@@ -342,7 +555,9 @@ func (p *Parser) lambda() (*ast.LambdaExpr, error) {
 		return nil, err
 	}
 
-	return ast.NewLambdaExpr(parameters, body), nil
+	lambda := ast.NewLambdaExpr(keyword, parameters, body)
+	p.attachEnd(lambda)
+	return lambda, nil
 }
 
 func (p *Parser) block() ([]ast.Stmt, error) {
@@ -351,11 +566,14 @@ func (p *Parser) block() ([]ast.Stmt, error) {
 	for !p.check(RightBrace) && !p.isAtEnd() {
 		declaration, err := p.declaration()
 		if err != nil {
-			return nil, err
+			// The error has been recorded and declaration synchronized, so
+			// carry on with the rest of the block.
+			continue
 		}
 		statements = append(statements, declaration)
 	}
 
+	p.endComments()
 	_, err := p.consume(RightBrace, "Expect '}' after block.")
 	if err != nil {
 		return nil, err
@@ -382,6 +600,9 @@ func (p *Parser) assigment() (ast.Expr, error) {
 		if get, ok := exp.(*ast.GetExpr); ok {
 			return ast.NewSetExpr(get.Object, get.Name, value), nil
 		}
+		if get, ok := exp.(*ast.IndexGetExpr); ok {
+			return ast.NewIndexSetExpr(get.Object, get.Bracket, get.Index, value), nil
+		}
 
 		return nil, p.error(equals, "Invalid assigment target.")
 	}
@@ -448,6 +669,7 @@ func (p *Parser) varDeclaration() (ast.Stmt, error) {
 }
 
 func (p *Parser) whileStatement() (ast.Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(LeftParen, "Expect '(' after 'while'.")
 	if err != nil {
 		return nil, err
@@ -468,7 +690,7 @@ func (p *Parser) whileStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return ast.NewWhileStmt(condition, body), nil
+	return ast.NewWhileStmt(keyword, condition, body, nil), nil
 }
 
 func (p *Parser) breakStatement() (ast.Stmt, error) {
@@ -480,6 +702,15 @@ func (p *Parser) breakStatement() (ast.Stmt, error) {
 	return ast.NewBreakStmt(keyword), nil
 }
 
+func (p *Parser) continueStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(Semicolon, "Expect ';' after 'continue'.")
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewContinueStmt(keyword), nil
+}
+
 func (p *Parser) equality() (ast.Expr, error) {
 	exp, err := p.comparison()
 	if err != nil {
@@ -535,8 +766,10 @@ func (p *Parser) previous() Token {
 }
 
 func (p *Parser) error(token Token, message string) errors.SyntaxError {
-	errors.Error(token, message)
-	return errors.NewSyntaxError(token, message)
+	err := errors.NewSyntaxError(token, message)
+	p.diagnostics.Error(token, message)
+	p.errors = append(p.errors, err)
+	return err
 }
 
 func (p *Parser) comparison() (ast.Expr, error) {
@@ -582,7 +815,7 @@ func (p *Parser) factor() (ast.Expr, error) {
 		return nil, err
 	}
 
-	for p.match(Slash, Star) {
+	for p.match(Slash, Star, Percent, TildeSlash) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -606,7 +839,29 @@ func (p *Parser) unary() (ast.Expr, error) {
 		return ast.NewUnaryExpr(operator, right), nil
 	}
 
-	return p.call()
+	return p.power()
+}
+
+// power parses an exponentiation. It's right-associative, and binds tighter
+// than a unary operator on its left but not on its right: -2 ** 2 is -4, and
+// 2 ** -1 is 0.5.
+func (p *Parser) power() (ast.Expr, error) {
+	exp, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(StarStar) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		exp = ast.NewBinaryExpr(exp, operator, right)
+	}
+
+	return exp, nil
 }
 
 func (p *Parser) finishCall(callee ast.Expr) (ast.Expr, error) {
@@ -653,6 +908,17 @@ func (p *Parser) call() (ast.Expr, error) {
 				return nil, err
 			}
 			exp = ast.NewGetExpr(exp, *name)
+		} else if p.match(LeftBracket) {
+			bracket := p.previous()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.consume(RightBracket, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+			exp = ast.NewIndexGetExpr(exp, bracket, index)
 		} else {
 			break
 		}
@@ -664,20 +930,38 @@ func (p *Parser) call() (ast.Expr, error) {
 func (p *Parser) primary() (ast.Expr, error) {
 	switch {
 	case p.match(False):
-		return ast.NewLiteralExpr(false), nil
+		return ast.NewLiteralExpr(p.previous(), false), nil
 	case p.match(True):
-		return ast.NewLiteralExpr(true), nil
+		return ast.NewLiteralExpr(p.previous(), true), nil
 	case p.match(Nil):
-		return ast.NewLiteralExpr(nil), nil
+		return ast.NewLiteralExpr(p.previous(), nil), nil
 	case p.match(Number, String):
-		return ast.NewLiteralExpr(*p.previous().Literal), nil
+		return ast.NewLiteralExpr(p.previous(), *p.previous().Literal), nil
+	case p.match(Interpolation):
+		return p.interpolation()
 	case p.match(Fun):
 		return p.lambda()
+	case p.match(Super):
+		keyword := p.previous()
+		_, err := p.consume(Dot, "Expect '.' after 'super'.")
+		if err != nil {
+			return nil, err
+		}
+		method, err := p.consume(Identifier, "Expect superclass method name.")
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewSuperExpr(keyword, *method), nil
 	case p.match(This):
 		return ast.NewThisExpr(p.previous()), nil
 	case p.match(Identifier):
 		return ast.NewVariableExpr(p.previous()), nil
+	case p.match(LeftBracket):
+		return p.list()
+	case p.match(LeftBrace):
+		return p.mapLiteral()
 	case p.match(LeftParen):
+		paren := p.previous()
 		exp, err := p.expression()
 		if err != nil {
 			return nil, err
@@ -688,12 +972,71 @@ func (p *Parser) primary() (ast.Expr, error) {
 			return nil, err
 		}
 
-		return ast.NewGroupingExpr(exp), nil
+		return ast.NewGroupingExpr(paren, exp), nil
 	}
 
 	return nil, p.error(p.peek(), "Expect expression.")
 }
 
+func (p *Parser) list() (ast.Expr, error) {
+	bracket := p.previous()
+
+	var elements []ast.Expr
+	for !p.check(RightBracket) {
+		element, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+
+		if !p.match(Comma) {
+			break
+		}
+	}
+
+	_, err := p.consume(RightBracket, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewListExpr(bracket, elements), nil
+}
+
+// mapLiteral parses a map literal. A '{' only starts a map where an expression
+// is expected; at the start of a statement it always starts a block.
+func (p *Parser) mapLiteral() (ast.Expr, error) {
+	brace := p.previous()
+
+	var keys, values []ast.Expr
+	for !p.check(RightBrace) {
+		key, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(Colon, "Expect ':' after map key.")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+
+		if !p.match(Comma) {
+			break
+		}
+	}
+
+	_, err := p.consume(RightBrace, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewMapExpr(brace, keys, values), nil
+}
+
 func (p *Parser) consume(t TokenType, message string) (*Token, error) {
 	if p.check(t) {
 		return lo.ToPtr(p.advance()), nil
@@ -701,6 +1044,15 @@ func (p *Parser) consume(t TokenType, message string) (*Token, error) {
 	return nil, p.error(p.previous(), message)
 }
 
+// consumeWord consumes an identifier that has a meaning in this place, but
+// isn't reserved, like the 'as' of an import.
+func (p *Parser) consumeWord(word string, message string) (*Token, error) {
+	if p.check(Identifier) && p.peek().Lexeme == word {
+		return lo.ToPtr(p.advance()), nil
+	}
+	return nil, p.error(p.previous(), message)
+}
+
 func (p *Parser) synchronize() {
 	p.advance()
 
@@ -709,6 +1061,8 @@ func (p *Parser) synchronize() {
 			return
 		}
 		switch p.peek().Type {
+		case RightBrace:
+			fallthrough
 		case Class:
 			fallthrough
 		case Fun:
@@ -724,9 +1078,46 @@ func (p *Parser) synchronize() {
 		case Print:
 			fallthrough
 		case Return:
+			fallthrough
+		case Throw:
+			fallthrough
+		case Try:
+			fallthrough
+		case Import:
+			fallthrough
+		case Export:
 			return
 		}
 
 		p.advance()
 	}
 }
+
+// interpolation parses a string with interpolated expressions, which the
+// scanner split into parts: "a ${b} c ${d} e" is the parts "a ${, } c ${
+// and } e", with the tokens of the expressions in between. It becomes the
+// concatenation of the parts and the expressions, joined with the
+// interpolation operator, which is the first part.
+func (p *Parser) interpolation() (ast.Expr, error) {
+	operator := p.previous()
+	var expr ast.Expr = ast.NewLiteralExpr(operator, *operator.Literal)
+	for {
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.NewBinaryExpr(expr, operator, value)
+
+		if p.match(InterpolationMiddle) {
+			part := p.previous()
+			expr = ast.NewBinaryExpr(expr, operator, ast.NewLiteralExpr(part, *part.Literal))
+			continue
+		}
+
+		end, err := p.consume(InterpolationEnd, "Expect '}' after interpolated expression.")
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewBinaryExpr(expr, operator, ast.NewLiteralExpr(*end, *end.Literal)), nil
+	}
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"interp/ast"
+	"interp/errors"
+	"interp/scanner"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{
+			"print 1 + 2 * 3;",
+			`(PrintStmt @1:1 keyword=print
+  expression: (BinaryExpr @1:7 operator=+
+    left: (LiteralExpr @1:7 token=1 value=1)
+    right: (BinaryExpr @1:11 operator=*
+      left: (LiteralExpr @1:11 token=2 value=2)
+      right: (LiteralExpr @1:15 token=3 value=3))))
+`,
+		},
+		{
+			// ** is right-associative and binds tighter than a unary operator
+			// on its left.
+			"print -2 ** 3 ** -1;",
+			`(PrintStmt @1:1 keyword=print
+  expression: (UnaryExpr @1:7 operator=-
+    right: (BinaryExpr @1:8 operator=**
+      left: (LiteralExpr @1:8 token=2 value=2)
+      right: (BinaryExpr @1:13 operator=**
+        left: (LiteralExpr @1:13 token=3 value=3)
+        right: (UnaryExpr @1:18 operator=-
+          right: (LiteralExpr @1:19 token=1 value=1))))))
+`,
+		},
+		{
+			"a.b = c[0] = !d;",
+			`(ExpressionStmt @1:1
+  expression: (SetExpr @1:1 name=b
+    object: (VariableExpr @1:1 name=a)
+    value: (IndexSetExpr @1:7 bracket="["
+      object: (VariableExpr @1:7 name=c)
+      index: (LiteralExpr @1:9 token=0 value=0)
+      value: (UnaryExpr @1:14 operator=!
+        right: (VariableExpr @1:15 name=d)))))
+`,
+		},
+		{
+			// For loops become while loops in a block with the initializer.
+			"for (var i = 0; i < 3; i = i + 1) print i;",
+			`(BlockStmt @1:1 brace=for
+  statements: [
+    (VarStmt @1:10 name=i
+      initializer: (LiteralExpr @1:14 token=0 value=0))
+    (WhileStmt @1:1 keyword=for
+      condition: (BinaryExpr @1:17 operator=<
+        left: (VariableExpr @1:17 name=i)
+        right: (LiteralExpr @1:21 token=3 value=3))
+      body: (PrintStmt @1:35 keyword=print
+        expression: (VariableExpr @1:41 name=i))
+      increment: (AssignExpr @1:24 name=i
+        value: (BinaryExpr @1:28 operator=+
+          left: (VariableExpr @1:28 name=i)
+          right: (LiteralExpr @1:32 token=1 value=1))))])
+`,
+		},
+		{
+			"for (;;) break;",
+			`(WhileStmt @1:1 keyword=for increment=nil
+  condition: (LiteralExpr @1:1 token=for value=true)
+  body: (BreakStmt @1:10 keyword=break))
+`,
+		},
+		{
+			"if (a) {} else if (b) print b;",
+			`(IfStmt @1:1 keyword=if
+  condition: (VariableExpr @1:5 name=a)
+  thenBranch: (BlockStmt @1:8 brace={ statements=[])
+  elseBranch: (IfStmt @1:16 keyword=if elseBranch=nil
+    condition: (VariableExpr @1:20 name=b)
+    thenBranch: (PrintStmt @1:23 keyword=print
+      expression: (VariableExpr @1:29 name=b))))
+`,
+		},
+	}
+
+	for _, test := range tests {
+		diagnostics := errors.NewDiagnostics("")
+		tokens, _ := scanner.NewScanner(test.source, diagnostics).ScanTokens()
+		parser := NewParser(tokens, diagnostics)
+		statements, err := parser.Parse()
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if got := ast.Sexpr(statements); got != test.want {
+			t.Errorf("%s parsed to\n%s\nwant\n%s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		// The parts of a string after an interpolated expression aren't
+		// strings of their own, so they can't be operands.
+		{`print "${}";`, "1:10: error: Expect expression."},
+		{`print "${1 +}x";`, "1:13: error: Expect expression."},
+		{`print "${1} and ${}";`, "1:19: error: Expect expression."},
+		{`print "${1 2}";`, "1:10: error: Expect '}' after interpolated expression."},
+	}
+
+	for _, test := range tests {
+		diagnostics := errors.NewDiagnostics("")
+		tokens, _ := scanner.NewScanner(test.source, diagnostics).ScanTokens()
+		parser := NewParser(tokens, diagnostics)
+		_, _ = parser.Parse()
+
+		items := diagnostics.Items()
+		if len(items) != 1 {
+			t.Errorf("%s: got %d errors, want 1", test.source, len(items))
+			continue
+		}
+		if got := items[0].String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.source, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"interp/ast"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/parser"
+	"interp/resolver"
+	"interp/scanner"
+	"interp/token"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	prompt             = "> "
+	continuationPrompt = "... "
+)
+
+// repl reads input line by line and runs it against a single interpreter,
+// so declarations made on one line stay visible on the next. Input that is
+// not finished yet is buffered until it is; an empty line forces the buffered
+// input to be evaluated as is.
+func repl(in io.Reader, options options) int {
+	inter := interpreter.NewInterpreter()
+	inter.SetSearchPath(options.searchPath)
+	inter.Define("args", interpreter.NewList(nil))
+
+	lines := bufio.NewScanner(in)
+	var buffer strings.Builder
+
+	for {
+		if buffer.Len() == 0 {
+			fmt.Print(prompt)
+		} else {
+			fmt.Print(continuationPrompt)
+		}
+
+		if !lines.Scan() {
+			fmt.Println()
+			return exitOK
+		}
+		line := lines.Text()
+
+		force := buffer.Len() > 0 && strings.TrimSpace(line) == ""
+		buffer.WriteString(line)
+		buffer.WriteString("\n")
+
+		if evaluate(&inter, buffer.String(), force) {
+			buffer.Reset()
+		}
+	}
+}
+
+// evaluate runs the source and reports whether it was consumed. It returns
+// false when the source is incomplete and more input should be read, unless
+// force is set.
+//
+//goland:noinspection GoTypeAssertionOnErrors
+func evaluate(inter *interpreter.Interpreter, source string, force bool) bool {
+	diagnostics := errors.NewDiagnostics("")
+
+	scan := scanner.NewScanner(source, diagnostics)
+	tokens, err := scan.ScanTokens()
+	if err != nil {
+		if err, ok := err.(scanner.ScanError); ok && err.Incomplete() && !force {
+			return false
+		}
+		diagnostics.Print(os.Stdout, source)
+		return true
+	}
+
+	// Only the EOF token, nothing to run.
+	if len(tokens) == 1 {
+		return true
+	}
+
+	if !force && openBrackets(tokens) > 0 {
+		return false
+	}
+
+	var statements []ast.Stmt
+
+	// Input that is a whole expression, including one ending in a brace like
+	// a map literal, is evaluated and its value printed. Anything else is a
+	// statement, which isn't finished yet unless it ends like one.
+	par := parser.NewParser(tokens, errors.NewDiagnostics(""))
+	if expression, err := par.ParseExpression(); err == nil {
+		statements = []ast.Stmt{ast.NewPrintStmt(tokens[0], expression)}
+	} else {
+		last := tokens[len(tokens)-2].Type
+		if last != token.Semicolon && last != token.RightBrace && !force {
+			return false
+		}
+
+		par := parser.NewParser(tokens, diagnostics)
+		statements, _ = par.Parse()
+		if diagnostics.HasErrors() {
+			diagnostics.Print(os.Stdout, source)
+			return true
+		}
+	}
+
+	res := resolver.NewResolver(inter, diagnostics)
+	if err := res.Resolve(statements); err != nil {
+		fmt.Println(err)
+	}
+	diagnostics.Print(os.Stdout, source)
+	if diagnostics.HasErrors() {
+		return true
+	}
+
+	err = inter.Interpret(statements)
+	if err != nil {
+		if err, ok := err.(errors.RuntimeError); ok {
+			err.Print(&source)
+		} else {
+			fmt.Println(err)
+		}
+	}
+
+	return true
+}
+
+// openBrackets returns the number of parentheses, braces and brackets that
+// have been opened but not closed yet.
+func openBrackets(tokens []token.Token) int {
+	depth := 0
+	for _, t := range tokens {
+		switch t.Type {
+		case token.LeftParen, token.LeftBrace, token.LeftBracket:
+			depth++
+		case token.RightParen, token.RightBrace, token.RightBracket:
+			depth--
+		}
+	}
+	return depth
+}
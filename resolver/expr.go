@@ -2,16 +2,13 @@ package resolver
 
 import (
 	"interp/ast"
-	"interp/errors"
 )
 
 func (r *Resolver) VisitVariableExpr(expr *ast.VariableExpr) (any, error) {
-	scope, ok := r.scopes.peek()
-	if !ok {
-		return nil, nil
-	}
-	if scope[expr.Name.Lexeme].defined == false {
-		errors.Error(expr.Name, "Can't read local variable in its own initializer.")
+	if scope, ok := r.scopes.peek(); ok {
+		if state, ok := scope[expr.Name.Lexeme]; ok && !state.defined {
+			r.diagnostics.Error(expr.Name, "Can't read local variable in its own initializer.")
+		}
 	}
 	r.resolveLocal(expr, expr.Name)
 	return nil, nil
@@ -23,7 +20,7 @@ func (r *Resolver) VisitAssignExpr(expr *ast.AssignExpr) (any, error) {
 		return nil, err
 	}
 
-	r.resolveLocal(expr.Value, expr.Name)
+	r.resolveLocal(expr, expr.Name)
 
 	return nil, nil
 }
@@ -61,10 +58,40 @@ func (r *Resolver) VisitGroupingExpr(expr *ast.GroupingExpr) (any, error) {
 	return nil, r.resolveExpr(expr.Expression)
 }
 
+func (r *Resolver) VisitIndexGetExpr(expr *ast.IndexGetExpr) (any, error) {
+	err := r.resolveExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Index)
+}
+
+func (r *Resolver) VisitIndexSetExpr(expr *ast.IndexSetExpr) (any, error) {
+	err := r.resolveExpr(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	err = r.resolveExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Index)
+}
+
 func (r *Resolver) VisitLambdaExpr(expr *ast.LambdaExpr) (any, error) {
 	return nil, r.resolveLambda(expr)
 }
 
+func (r *Resolver) VisitListExpr(expr *ast.ListExpr) (any, error) {
+	for _, element := range expr.Elements {
+		err := r.resolveExpr(element)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
 func (r *Resolver) VisitLiteralExpr(_ *ast.LiteralExpr) (any, error) {
 	return nil, nil
 }
@@ -78,6 +105,20 @@ func (r *Resolver) VisitLogicalExpr(expr *ast.LogicalExpr) (any, error) {
 	return nil, r.resolveExpr(expr.Right)
 }
 
+func (r *Resolver) VisitMapExpr(expr *ast.MapExpr) (any, error) {
+	for index, key := range expr.Keys {
+		err := r.resolveExpr(key)
+		if err != nil {
+			return nil, err
+		}
+		err = r.resolveExpr(expr.Values[index])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
 func (r *Resolver) VisitSetExpr(expr *ast.SetExpr) (any, error) {
 	err := r.resolveExpr(expr.Value)
 	if err != nil {
@@ -86,9 +127,21 @@ func (r *Resolver) VisitSetExpr(expr *ast.SetExpr) (any, error) {
 	return nil, r.resolveExpr(expr.Object)
 }
 
+func (r *Resolver) VisitSuperExpr(expr *ast.SuperExpr) (any, error) {
+	switch r.currentClass {
+	case ClassTypeNone:
+		r.diagnostics.Error(expr.Keyword, "Can't use 'super' outside of a class.")
+	case ClassTypeClass:
+		r.diagnostics.Error(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+	}
+
+	r.resolveLocal(expr, expr.Keyword)
+	return nil, nil
+}
+
 func (r *Resolver) VisitThisExpr(expr *ast.ThisExpr) (any, error) {
 	if r.currentClass == ClassTypeNone {
-		errors.Error(expr.Keyword, "Can't use 'this' outside of a class.")
+		r.diagnostics.Error(expr.Keyword, "Can't use 'this' outside of a class.")
 	}
 
 	r.resolveLocal(expr, expr.Keyword)
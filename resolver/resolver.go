@@ -4,27 +4,50 @@ import (
 	"fmt"
 	"interp/ast"
 	"interp/errors"
-	"interp/interpreter"
 	"interp/token"
 )
 
+// Locals is told, for every use of a local variable, how many scopes away
+// from the use the variable was declared, and the slot it was given in that
+// scope. Slots are numbered from 0 in the order variables are declared.
+type Locals interface {
+	Resolve(expr ast.Expr, depth int, slot int)
+}
+
+// Symbols is told, for every use of a variable, where it was declared. Tools
+// such as the language server use it to link names to their declarations.
+type Symbols interface {
+	// Use is called with the token of the use and that of the declaration.
+	// The declaration is nil for globals, which are looked up by name when
+	// the script runs.
+	Use(name token.Token, declaration *token.Token)
+}
+
 type Resolver struct {
-	interpreter     *interpreter.Interpreter
+	locals          Locals
+	symbols         Symbols
 	scopes          stack[map[string]*varState]
 	currentFunction FunctionType
 	currentClass    ClassType
 	inLoop          bool
+	diagnostics     *errors.Diagnostics
 }
 
-func NewResolver(interpreter *interpreter.Interpreter) Resolver {
+func NewResolver(locals Locals, diagnostics *errors.Diagnostics) Resolver {
 	return Resolver{
-		interpreter:     interpreter,
+		locals:          locals,
 		currentFunction: FunctionTypeNone,
 		currentClass:    ClassTypeNone,
 		scopes:          stack[map[string]*varState]{},
+		diagnostics:     diagnostics,
 	}
 }
 
+// SetSymbols sets what is told where the variables used are declared.
+func (r *Resolver) SetSymbols(symbols Symbols) {
+	r.symbols = symbols
+}
+
 func (r *Resolver) Resolve(statements []ast.Stmt) error {
 	for _, statement := range statements {
 		err := r.resolveStmt(statement)
@@ -35,6 +58,31 @@ func (r *Resolver) Resolve(statements []ast.Stmt) error {
 	return nil
 }
 
+// ResolveExprIn resolves an expression as if it was used in the given scopes,
+// outermost first, each of which lists the names of its variables in slot
+// order. Debuggers use it to evaluate expressions where a script is paused.
+func (r *Resolver) ResolveExprIn(expr ast.Expr, scopes [][]string) error {
+	for _, names := range scopes {
+		scope := map[string]*varState{}
+		for slot, name := range names {
+			scope[name] = &varState{defined: true, resolved: true, token: token.Token{Lexeme: name}, slot: slot}
+			switch {
+			case name == "super":
+				r.currentClass = ClassTypeSubclass
+			case name == "this" && r.currentClass == ClassTypeNone:
+				r.currentClass = ClassTypeClass
+			}
+		}
+		r.scopes.push(scope)
+	}
+
+	err := r.resolveExpr(expr)
+	for range scopes {
+		r.scopes.pop()
+	}
+	return err
+}
+
 func (r *Resolver) resolveStmt(stmt ast.Stmt) error {
 	_, err := stmt.Accept(r)
 	return err
@@ -48,6 +96,8 @@ func (r *Resolver) resolveExpr(expr ast.Expr) error {
 func (r *Resolver) resolveFunction(function *ast.FunctionStmt, funcType FunctionType) error {
 	enclosingFunction := r.currentFunction
 	r.currentFunction = funcType
+	enclosingLoop := r.inLoop
+	r.inLoop = false
 
 	r.beginScope()
 	for _, param := range function.Params {
@@ -57,7 +107,7 @@ func (r *Resolver) resolveFunction(function *ast.FunctionStmt, funcType Function
 	// TODO: Fix for nested returns
 	for i, statement := range function.Body {
 		if returnStmt, ok := statement.(*ast.ReturnStmt); ok && i != len(function.Body)-1 {
-			errors.Warning(returnStmt.Keyword, "Unreachable code after return.")
+			r.diagnostics.Warning(returnStmt.Keyword, "Unreachable code after return.")
 		}
 	}
 	err := r.Resolve(function.Body)
@@ -67,12 +117,15 @@ func (r *Resolver) resolveFunction(function *ast.FunctionStmt, funcType Function
 
 	r.endScope()
 	r.currentFunction = enclosingFunction
+	r.inLoop = enclosingLoop
 	return nil
 }
 
 func (r *Resolver) resolveLambda(lambda *ast.LambdaExpr) error {
 	enclosingFunction := r.currentFunction
 	r.currentFunction = FunctionTypeFunction
+	enclosingLoop := r.inLoop
+	r.inLoop = false
 
 	r.beginScope()
 	for _, param := range lambda.Params {
@@ -82,7 +135,7 @@ func (r *Resolver) resolveLambda(lambda *ast.LambdaExpr) error {
 	// TODO: Fix for nested returns
 	for i, statement := range lambda.Body {
 		if returnStmt, ok := statement.(*ast.ReturnStmt); ok && i != len(lambda.Body)-1 {
-			errors.Warning(returnStmt.Keyword, "Unreachable code after return.")
+			r.diagnostics.Warning(returnStmt.Keyword, "Unreachable code after return.")
 		}
 	}
 	err := r.Resolve(lambda.Body)
@@ -91,6 +144,7 @@ func (r *Resolver) resolveLambda(lambda *ast.LambdaExpr) error {
 	}
 	r.endScope()
 	r.currentFunction = enclosingFunction
+	r.inLoop = enclosingLoop
 	return nil
 }
 
@@ -106,7 +160,7 @@ func (r *Resolver) endScope() {
 	}
 	for name, state := range scope {
 		if !state.resolved {
-			errors.Warning(state.token, fmt.Sprintf("Variable '%s' is declared but never used.", name))
+			r.diagnostics.Warning(state.token, fmt.Sprintf("Variable '%s' is declared but never used.", name))
 		}
 	}
 }
@@ -120,9 +174,9 @@ func (r *Resolver) declare(name token.Token) {
 		return
 	}
 	if _, ok = scope[name.Lexeme]; ok {
-		errors.Error(name, "Already a variable with this name in this scope.")
+		r.diagnostics.Error(name, "Already a variable with this name in this scope.")
 	}
-	scope[name.Lexeme] = &varState{token: name}
+	scope[name.Lexeme] = &varState{token: name, slot: len(scope)}
 }
 
 func (r *Resolver) define(name token.Token) {
@@ -137,15 +191,17 @@ func (r *Resolver) define(name token.Token) {
 }
 
 func (r *Resolver) resolveLocal(expr ast.Expr, name token.Token) {
-	scope, ok := r.scopes.peek()
-	if !ok {
-		return
-	}
-	scope[name.Lexeme].resolve()
 	for i := r.scopes.size() - 1; i >= 0; i-- {
-		if _, ok = r.scopes.get(i)[name.Lexeme]; ok {
-			r.interpreter.Resolve(expr, r.scopes.size()-1-i)
+		if state, ok := r.scopes.get(i)[name.Lexeme]; ok {
+			state.resolve()
+			r.locals.Resolve(expr, r.scopes.size()-1-i, state.slot)
+			if r.symbols != nil {
+				r.symbols.Use(name, &state.token)
+			}
 			return
 		}
 	}
+	if r.symbols != nil {
+		r.symbols.Use(name, nil)
+	}
 }
@@ -2,7 +2,6 @@ package resolver
 
 import (
 	"interp/ast"
-	"interp/errors"
 )
 
 func (r *Resolver) VisitBlockStmt(stmt *ast.BlockStmt) (any, error) {
@@ -22,6 +21,22 @@ func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
 	r.declare(stmt.Name)
 	r.define(stmt.Name)
 
+	if stmt.Superclass != nil {
+		if stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
+			r.diagnostics.Error(stmt.Superclass.Name, "A class can't inherit from itself.")
+		}
+
+		r.currentClass = ClassTypeSubclass
+		err := r.resolveExpr(stmt.Superclass)
+		if err != nil {
+			return nil, err
+		}
+
+		r.beginScope()
+		scope, _ := r.scopes.peek()
+		scope["super"] = &varState{defined: true, resolved: true}
+	}
+
 	r.beginScope()
 	scope, ok := r.scopes.peek()
 	if !ok {
@@ -43,6 +58,10 @@ func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
 
 	r.endScope()
 
+	if stmt.Superclass != nil {
+		r.endScope()
+	}
+
 	r.currentClass = enclosingClass
 	return nil, nil
 }
@@ -93,12 +112,12 @@ func (r *Resolver) VisitPrintStmt(stmt *ast.PrintStmt) (any, error) {
 
 func (r *Resolver) VisitReturnStmt(stmt *ast.ReturnStmt) (any, error) {
 	if r.currentFunction == FunctionTypeNone {
-		errors.Error(stmt.Keyword, "Can't return from top-level code.")
+		r.diagnostics.Error(stmt.Keyword, "Can't return from top-level code.")
 	}
 
 	if stmt.Value != nil {
 		if r.currentFunction == FunctionTypeInitializer {
-			errors.Error(stmt.Keyword, "Can't return a value from an initializer.")
+			r.diagnostics.Error(stmt.Keyword, "Can't return a value from an initializer.")
 		}
 		err := r.resolveExpr(stmt.Value)
 		if err != nil {
@@ -122,6 +141,13 @@ func (r *Resolver) VisitWhileStmt(stmt *ast.WhileStmt) (any, error) {
 		return nil, err
 	}
 
+	if stmt.Increment != nil {
+		err = r.resolveExpr(stmt.Increment)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	r.inLoop = enclosingLoop
 	return nil, nil
 }
@@ -132,7 +158,71 @@ func (r *Resolver) VisitExpressionStmt(stmt *ast.ExpressionStmt) (any, error) {
 
 func (r *Resolver) VisitBreakStmt(stmt *ast.BreakStmt) (any, error) {
 	if !r.inLoop {
-		errors.Error(stmt.Keyword, "Can't break outside loop")
+		r.diagnostics.Error(stmt.Keyword, "Can't break outside loop")
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitContinueStmt(stmt *ast.ContinueStmt) (any, error) {
+	if !r.inLoop {
+		r.diagnostics.Error(stmt.Keyword, "Can't continue outside loop")
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitThrowStmt(stmt *ast.ThrowStmt) (any, error) {
+	return nil, r.resolveExpr(stmt.Value)
+}
+
+func (r *Resolver) VisitTryStmt(stmt *ast.TryStmt) (any, error) {
+	err := r.resolveStmt(stmt.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Catch != nil {
+		r.beginScope()
+		r.declare(stmt.Catch.Name)
+		r.define(stmt.Catch.Name)
+		// The variable is required by the syntax, so it isn't reported when
+		// it goes unused.
+		scope, _ := r.scopes.peek()
+		scope[stmt.Catch.Name.Lexeme].resolve()
+		err = r.Resolve(stmt.Catch.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.endScope()
+	}
+
+	if stmt.Finally != nil {
+		err = r.resolveStmt(stmt.Finally)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitImportStmt(stmt *ast.ImportStmt) (any, error) {
+	if !r.scopes.isEmpty() {
+		r.diagnostics.Error(stmt.Keyword, "Can only import at the top level.")
+	}
+
+	if stmt.Alias != nil {
+		r.declare(*stmt.Alias)
+		r.define(*stmt.Alias)
+	}
+	for _, name := range stmt.Names {
+		r.declare(name)
+		r.define(name)
 	}
 	return nil, nil
 }
+
+func (r *Resolver) VisitExportStmt(stmt *ast.ExportStmt) (any, error) {
+	if !r.scopes.isEmpty() {
+		r.diagnostics.Error(stmt.Keyword, "Can only export top-level declarations.")
+	}
+	return nil, r.resolveStmt(stmt.Declaration)
+}
@@ -12,6 +12,7 @@ const (
 type ClassType string
 
 const (
-	ClassTypeNone  ClassType = "none"
-	ClassTypeClass ClassType = "class"
+	ClassTypeNone     ClassType = "none"
+	ClassTypeClass    ClassType = "class"
+	ClassTypeSubclass ClassType = "subclass"
 )
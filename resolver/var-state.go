@@ -6,6 +6,8 @@ type varState struct {
 	defined  bool
 	resolved bool
 	token    token.Token
+	// slot is the index of the variable in its scope.
+	slot int
 }
 
 func (v *varState) define() {
@@ -3,14 +3,26 @@ package scanner
 import (
 	"fmt"
 	"github.com/samber/lo"
+	"interp/errors"
 	"interp/token"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type ScanError struct {
-	line    int
-	where   string
-	message string
+	line       int
+	column     int
+	where      string
+	message    string
+	incomplete bool
+}
+
+// Incomplete reports whether the error was caused by the source ending
+// before a token was finished, e.g. an unterminated string.
+func (s ScanError) Incomplete() bool {
+	return s.incomplete
 }
 
 func (s ScanError) Error() string {
@@ -18,38 +30,89 @@ func (s ScanError) Error() string {
 }
 
 type Scanner struct {
-	source string
-	tokens []token.Token
+	source      string
+	tokens      []token.Token
+	comments    []token.Token
+	diagnostics *errors.Diagnostics
+	firstErr    error
+
+	start   int
+	current int
+	line    int
+	// column is the column of the current character, and startColumn that
+	// of the start of the token being scanned.
+	column      int
+	startColumn int
+
+	// interpolations are the strings whose interpolated expressions are
+	// being scanned, innermost last.
+	interpolations []interpolation
+}
 
-	start     int
-	current   int
-	line      int
-	lineStart int
+// interpolation is a string with an interpolated expression in it. The
+// string goes on at the brace that closes the expression, which is the one
+// found when depth braces opened in the expression have been closed.
+type interpolation struct {
+	line   int
+	column int
+	depth  int
 }
 
-func NewScanner(source string) *Scanner {
+func NewScanner(source string, diagnostics *errors.Diagnostics) *Scanner {
 	return &Scanner{
-		source: source,
-		line:   1,
+		source:      source,
+		diagnostics: diagnostics,
+		line:        1,
 	}
 }
 
+// ScanTokens scans the whole source, which must be UTF-8. Every error found
+// is reported to the diagnostics; the first one is also returned, along with
+// the tokens that could be scanned. Columns count characters, not bytes.
+//
+//goland:noinspection GoTypeAssertionOnErrors
 func (s *Scanner) ScanTokens() ([]token.Token, error) {
+	incomplete := false
 	for !s.isAtEnd() {
-		s.start = s.current
+		s.start, s.startColumn = s.current, s.column
 		err := s.scanToken()
-		if err != nil {
-			return nil, err
+		if err, ok := err.(ScanError); ok {
+			s.report(err)
+			incomplete = err.incomplete
 		}
 	}
 
+	// The source can end in an interpolated expression; unless it ended in a
+	// string in it, which has been reported already.
+	if len(s.interpolations) > 0 && !incomplete {
+		outermost := s.interpolations[0]
+		err := s.error(outermost.line, outermost.column, "Unterminated string.")
+		err.incomplete = true
+		s.report(err)
+	}
+
 	s.tokens = append(s.tokens, token.Token{
 		Type:   token.EOF,
 		Line:   s.line,
-		Column: s.start - s.lineStart,
+		Column: s.column,
 	})
 
-	return s.tokens, nil
+	return s.tokens, s.firstErr
+}
+
+// report reports an error to the diagnostics, and keeps the first one to be
+// returned by ScanTokens.
+func (s *Scanner) report(err ScanError) {
+	s.diagnostics.ErrorAt(err.line, err.column, 1, err.message)
+	if s.firstErr == nil {
+		s.firstErr = err
+	}
+}
+
+// Comments returns the comments found by ScanTokens, in the order they
+// appear. They aren't among the tokens, so parsers never see them.
+func (s *Scanner) Comments() []token.Token {
+	return s.comments
 }
 
 func (s *Scanner) scanToken() error {
@@ -60,34 +123,57 @@ func (s *Scanner) scanToken() error {
 	case ')':
 		s.addToken(token.RightParen)
 	case '{':
+		if len(s.interpolations) > 0 {
+			s.interpolations[len(s.interpolations)-1].depth++
+		}
 		s.addToken(token.LeftBrace)
 	case '}':
+		if len(s.interpolations) > 0 {
+			last := &s.interpolations[len(s.interpolations)-1]
+			if last.depth == 0 {
+				s.interpolations = s.interpolations[:len(s.interpolations)-1]
+				return s.string(last.line, last.column, true)
+			}
+			last.depth--
+		}
 		s.addToken(token.RightBrace)
+	case '[':
+		s.addToken(token.LeftBracket)
+	case ']':
+		s.addToken(token.RightBracket)
 	case '.':
 		s.addToken(token.Dot)
 	case ';':
 		s.addToken(token.Semicolon)
 	case ',':
 		s.addToken(token.Comma)
+	case ':':
+		s.addToken(token.Colon)
 	case '+':
 		s.addToken(token.Plus)
 	case '-':
 		s.addToken(token.Minus)
 	case '*':
-		s.addToken(token.Star)
+		if s.match('*') {
+			s.addToken(token.StarStar)
+		} else {
+			s.addToken(token.Star)
+		}
+	case '%':
+		s.addToken(token.Percent)
+	case '~':
+		if !s.match('/') {
+			return s.error(s.line, s.startColumn, "Unexpected character.")
+		}
+		s.addToken(token.TildeSlash)
 	case '/':
 		if s.match('/') {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+			s.addComment(s.line, s.startColumn)
 		} else if s.match('*') {
-			for s.peek() != '*' && s.peekNext() != '/' && !s.isAtEnd() {
-				if s.peek() == '\n' {
-					s.line++
-				}
-				s.advance()
-			}
-			s.advanceN(2)
+			return s.blockComment()
 		} else {
 			s.addToken(token.Slash)
 		}
@@ -107,18 +193,21 @@ func (s *Scanner) scanToken() error {
 	case '\r':
 	case '\t':
 	case '\n':
-		s.line++
-		s.lineStart = s.current
+		s.newline()
 	case '"':
-		return s.string()
+		return s.string(s.line, s.startColumn, false)
+	case '`':
+		return s.rawString()
 	default:
 		switch {
 		case s.isDigit(c):
 			return s.number()
 		case s.isAlpha(c):
 			s.identifier()
+		case c == utf8.RuneError && s.current-s.start == 1:
+			// advance has reported the invalid byte.
 		default:
-			return s.error(s.line, "Unexpected character.")
+			return s.error(s.line, s.startColumn, "Unexpected character.")
 		}
 	}
 	return nil
@@ -133,7 +222,7 @@ func (s *Scanner) addToken(tokenType token.TokenType) {
 		Type:   tokenType,
 		Lexeme: s.source[s.start:s.current],
 		Line:   s.line,
-		Column: s.start - s.lineStart,
+		Column: s.startColumn,
 	})
 }
 
@@ -143,95 +232,385 @@ func (s *Scanner) addTokenLiteral(tokenType token.TokenType, literal any) {
 		Literal: &literal,
 		Lexeme:  s.source[s.start:s.current],
 		Line:    s.line,
-		Column:  s.start - s.lineStart,
+		Column:  s.startColumn,
 	})
 }
 
-func (s *Scanner) advance() uint8 {
-	s.current++
-	return s.source[s.current-1]
+// advance scans the next character. Bytes that aren't valid UTF-8 are
+// reported, and scanned as utf8.RuneError.
+func (s *Scanner) advance() rune {
+	c, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if c == utf8.RuneError && size == 1 {
+		s.report(s.error(s.line, s.column, "Invalid UTF-8 encoding."))
+	}
+	s.current += size
+	s.column++
+	return c
 }
 
+// advanceN skips n bytes, which must be ASCII characters.
 func (s *Scanner) advanceN(n int) {
 	s.current += n
+	s.column += n
 }
 
-func (s *Scanner) match(expected byte) bool {
+func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	if s.source[s.current] != expected {
+	c, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if c != expected {
 		return false
 	}
-	s.current++
+	s.current += size
+	s.column++
 	return true
 }
 
-func (s *Scanner) peek() uint8 {
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current]
+	c, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return c
 }
 
-func (s *Scanner) peekNext() uint8 {
-	if s.current+1 >= len(s.source) {
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current+1]
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+size >= len(s.source) {
+		return 0
+	}
+	c, _ := utf8.DecodeRuneInString(s.source[s.current+size:])
+	return c
+}
+
+// columnAt returns the column of the character at an offset on the current
+// line, counting back from the current character.
+func (s *Scanner) columnAt(offset int) int {
+	return s.column - utf8.RuneCountInString(s.source[offset:s.current])
 }
 
-func (s *Scanner) string() error {
+// string scans a string up to its closing quote, or up to an interpolated
+// expression, which is scanned as tokens of its own. The rest of the string
+// is scanned again from the brace that closes the expression. The string
+// started at the line and column; its parts are tokens where they start.
+// The parts after an expression, which is when the string is continued, have
+// token types of their own.
+func (s *Scanner) string(line int, column int, continued bool) error {
+	middle, end := token.Interpolation, token.String
+	if continued {
+		middle, end = token.InterpolationMiddle, token.InterpolationEnd
+	}
+	partLine, partColumn := s.line, s.startColumn
+	var value strings.Builder
+	var firstErr error
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+		c := s.advance()
+		switch {
+		case c == '\n':
+			s.newline()
+			value.WriteRune(c)
+		case c == '\\':
+			if err := s.escape(&value); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		case c == '$' && s.match('{'):
+			s.addStringToken(middle, value.String(), partLine, partColumn)
+			s.interpolations = append(s.interpolations, interpolation{line: line, column: column})
+			return firstErr
+		default:
+			value.WriteRune(c)
 		}
+	}
+
+	if s.isAtEnd() {
+		err := s.error(line, column, "Unterminated string.")
+		err.incomplete = true
+		return err
+	}
+
+	s.advance()
+	s.addStringToken(end, value.String(), partLine, partColumn)
+	return firstErr
+}
+
+// escape scans an escape sequence whose backslash has just been scanned, and
+// writes the character it stands for.
+func (s *Scanner) escape(value *strings.Builder) error {
+	line, column := s.line, s.column-1
+	if s.isAtEnd() {
+		return nil
+	}
+
+	c := s.advance()
+	switch c {
+	case 'n':
+		value.WriteByte('\n')
+	case 't':
+		value.WriteByte('\t')
+	case 'r':
+		value.WriteByte('\r')
+	case '0':
+		value.WriteByte(0)
+	case '"', '\\', '$':
+		value.WriteRune(c)
+	case 'u':
+		return s.unicodeEscape(value, line, column)
+	default:
+		if c == '\n' {
+			s.newline()
+		}
+		return s.error(line, column, fmt.Sprintf("Unknown escape sequence '\\%c'.", c))
+	}
+	return nil
+}
+
+// unicodeEscape scans the code point of a \u{...} escape sequence, which
+// started at the line and column.
+func (s *Scanner) unicodeEscape(value *strings.Builder, line int, column int) error {
+	if !s.match('{') {
+		return s.error(line, column, "Expect '{' after '\\u'.")
+	}
+
+	start := s.current
+	for s.isHexDigit(s.peek()) {
 		s.advance()
 	}
+	digits := s.source[start:s.current]
+	if !s.match('}') || len(digits) == 0 || len(digits) > 6 {
+		return s.error(line, column, "Invalid Unicode escape sequence.")
+	}
+
+	code, _ := strconv.ParseUint(digits, 16, 32)
+	if code > unicode.MaxRune || (code >= 0xD800 && code <= 0xDFFF) {
+		return s.error(line, column, "Invalid Unicode code point.")
+	}
+	value.WriteRune(rune(code))
+	return nil
+}
+
+// rawString scans a string between backticks, which has no escape sequences
+// or interpolation.
+func (s *Scanner) rawString() error {
+	line, column := s.line, s.startColumn
+	for s.peek() != '`' && !s.isAtEnd() {
+		if s.advance() == '\n' {
+			s.newline()
+		}
+	}
 
 	if s.isAtEnd() {
-		return s.error(s.line, "Unterminated string.")
+		err := s.error(line, column, "Unterminated string.")
+		err.incomplete = true
+		return err
 	}
 
 	s.advance()
+	s.addStringToken(token.String, s.source[s.start+1:s.current-1], line, column)
+	return nil
+}
+
+// addStringToken adds a string, or a part of one, that started at the line
+// and column.
+func (s *Scanner) addStringToken(tokenType token.TokenType, value string, line int, column int) {
+	var literal any = value
+	s.tokens = append(s.tokens, token.Token{
+		Type:    tokenType,
+		Literal: &literal,
+		Lexeme:  s.source[s.start:s.current],
+		Line:    line,
+		Column:  column,
+	})
+}
 
-	value := s.source[s.start+1 : s.current-1]
-	s.addTokenLiteral(token.String, value)
+// newline moves on to the line that starts after the newline just scanned.
+func (s *Scanner) newline() {
+	s.line++
+	s.column = 0
+}
+
+func (s *Scanner) blockComment() error {
+	line, column := s.line, s.startColumn
+	for !(s.peek() == '*' && s.peekNext() == '/') && !s.isAtEnd() {
+		if s.advance() == '\n' {
+			s.newline()
+		}
+	}
 
+	if s.isAtEnd() {
+		err := s.error(line, column, "Unterminated comment.")
+		err.incomplete = true
+		return err
+	}
+
+	s.advanceN(2)
+	s.addComment(line, column)
 	return nil
 }
 
-func (s *Scanner) isDigit(c uint8) bool {
+// addComment keeps the comment that has just been scanned, which started at
+// the line and column.
+func (s *Scanner) addComment(line int, column int) {
+	s.comments = append(s.comments, token.Token{
+		Type:   token.Comment,
+		Lexeme: s.source[s.start:s.current],
+		Line:   line,
+		Column: column,
+	})
+}
+
+func (s *Scanner) isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func (s *Scanner) isAlpha(c uint8) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+func (s *Scanner) isHexDigit(c rune) bool {
+	return s.isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isDigitInBase(c rune, base int) bool {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c-'0') < base
+	case c >= 'a' && c <= 'z':
+		return int(c-'a')+10 < base
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A')+10 < base
+	}
+	return false
+}
+
+// isAlpha reports whether an identifier can start with the character: a
+// letter, in any script, or an underscore.
+func (s *Scanner) isAlpha(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
 }
 
-func (s *Scanner) isAlphaNumeric(c uint8) bool {
-	return s.isAlpha(c) || s.isDigit(c)
+// isAlphaNumeric reports whether an identifier can go on with the
+// character. Besides letters, digits in any script and combining marks such
+// as accents can.
+func (s *Scanner) isAlphaNumeric(c rune) bool {
+	return s.isAlpha(c) || unicode.IsDigit(c) || unicode.In(c, unicode.Mn, unicode.Mc)
 }
 
+// number scans a number literal. Integers are decimal, or hexadecimal,
+// binary or octal after 0x, 0b or 0o, and floats have a fraction or an
+// exponent. Underscores can separate digits. Integers are int64, and floats
+// float64.
 func (s *Scanner) number() error {
-	for s.isDigit(s.peek()) {
-		s.advance()
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			return s.prefixedInteger(16, "hexadecimal")
+		case 'b', 'B':
+			return s.prefixedInteger(2, "binary")
+		case 'o', 'O':
+			return s.prefixedInteger(8, "octal")
+		}
 	}
 
+	if err := s.digits(s.start, s.isDigit); err != nil {
+		return err
+	}
+	float := false
 	if s.peek() == '.' && s.isDigit(s.peekNext()) {
+		float = true
 		s.advance()
-		for s.isDigit(s.peek()) {
+		if err := s.digits(s.current, s.isDigit); err != nil {
+			return err
+		}
+	}
+	if s.exponent() {
+		float = true
+		s.advance()
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+		if err := s.digits(s.current, s.isDigit); err != nil {
+			return err
+		}
 	}
 
-	value, err := strconv.ParseFloat(s.source[s.start:s.current], 64)
-	if err != nil {
-		return s.error(s.line, "Failed to Parse float.")
+	text := strings.ReplaceAll(s.source[s.start:s.current], "_", "")
+	if float {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return s.error(s.line, s.startColumn, "Number literal is out of range.")
+		}
+		s.addTokenLiteral(token.Number, value)
+		return nil
 	}
+	return s.integer(text, 10)
+}
 
-	s.addTokenLiteral(token.Number, value)
+// exponent reports whether an exponent follows: an e, an optional sign and
+// a digit.
+func (s *Scanner) exponent() bool {
+	if s.peek() != 'e' && s.peek() != 'E' {
+		return false
+	}
+	next := s.current + 1
+	if next < len(s.source) && (s.source[next] == '+' || s.source[next] == '-') {
+		next++
+	}
+	return next < len(s.source) && s.isDigit(rune(s.source[next]))
+}
+
+// prefixedInteger scans an integer literal after its base prefix. Letters
+// are scanned as digits, so that a digit that's wrong for the base is
+// reported, rather than starting an identifier.
+func (s *Scanner) prefixedInteger(base int, name string) error {
+	s.advance()
+	start := s.current
+	if err := s.digits(start, s.isAlphaNumeric); err != nil {
+		return err
+	}
+	if start == s.current {
+		return s.error(s.line, s.startColumn, fmt.Sprintf("Expect digits in %s literal.", name))
+	}
+	for offset, c := range s.source[start:s.current] {
+		if c != '_' && !isDigitInBase(c, base) {
+			return s.error(s.line, s.columnAt(start+offset), fmt.Sprintf("Invalid digit '%c' in %s literal.", c, name))
+		}
+	}
+	return s.integer(strings.ReplaceAll(s.source[start:s.current], "_", ""), base)
+}
+
+// digits scans the digits of a literal that started at start, and the
+// underscores that separate them.
+func (s *Scanner) digits(start int, isDigit func(rune) bool) error {
+	for isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+	text := s.source[start:s.current]
+	if index := separatorError(text); index >= 0 {
+		return s.error(s.line, s.columnAt(start+index), "Digit separators must be between digits.")
+	}
+	return nil
+}
 
+// separatorError returns the offset of the first underscore in the digits
+// that doesn't separate two of them, or -1.
+func separatorError(digits string) int {
+	for index := 0; index < len(digits); index++ {
+		if digits[index] != '_' {
+			continue
+		}
+		if index == 0 || index == len(digits)-1 || digits[index+1] == '_' {
+			return index
+		}
+	}
+	return -1
+}
+
+func (s *Scanner) integer(digits string, base int) error {
+	value, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		return s.error(s.line, s.startColumn, "Integer literal is too large.")
+	}
+	s.addTokenLiteral(token.Number, value)
 	return nil
 }
 
@@ -249,6 +628,6 @@ func (s *Scanner) identifier() {
 	s.addToken(tokenType)
 }
 
-func (s *Scanner) error(line int, message string) ScanError {
-	return ScanError{line: line, message: message}
+func (s *Scanner) error(line int, column int, message string) ScanError {
+	return ScanError{line: line, column: column, message: message}
 }
@@ -0,0 +1,262 @@
+package scanner
+
+import (
+	"interp/errors"
+	"interp/token"
+	"strings"
+	"testing"
+)
+
+func TestStrings(t *testing.T) {
+	type scanned struct {
+		tokenType token.TokenType
+		value     string
+		line      int
+		column    int
+	}
+	tests := []struct {
+		source string
+		want   []scanned
+	}{
+		{`"a\tb\"\\\u{1F600}"`, []scanned{{token.String, "a\tb\"\\😀", 1, 0}}},
+		{"`raw\\n${x}`", []scanned{{token.String, `raw\n${x}`, 1, 0}}},
+		{
+			// Tokens after a multi-line string are on the right column.
+			"\"one\ntwo\" x",
+			[]scanned{{token.String, "one\ntwo", 1, 0}, {token.Identifier, "", 2, 5}},
+		},
+		{
+			`"a ${b} c ${"d"}"`,
+			[]scanned{
+				{token.Interpolation, "a ", 1, 0},
+				{token.Identifier, "", 1, 5},
+				{token.InterpolationMiddle, " c ", 1, 6},
+				{token.String, "d", 1, 12},
+				{token.InterpolationEnd, "", 1, 15},
+			},
+		},
+		{
+			// Braces in the expression don't end it.
+			`"${ {} }!"`,
+			[]scanned{
+				{token.Interpolation, "", 1, 0},
+				{token.LeftBrace, "", 1, 4},
+				{token.RightBrace, "", 1, 5},
+				{token.InterpolationEnd, "!", 1, 7},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tokens, err := NewScanner(test.source, errors.NewDiagnostics("")).ScanTokens()
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		tokens = tokens[:len(tokens)-1]
+		if len(tokens) != len(test.want) {
+			t.Errorf("%s: scanned %d tokens, want %d", test.source, len(tokens), len(test.want))
+			continue
+		}
+		for i, want := range test.want {
+			got := tokens[i]
+			value := ""
+			if got.Literal != nil {
+				value = (*got.Literal).(string)
+			}
+			if got.Type != want.tokenType || value != want.value || got.Line != want.line || got.Column != want.column {
+				t.Errorf("%s: token %d is %s %q at %d:%d, want %s %q at %d:%d", test.source, i,
+					got.Type, value, got.Line, got.Column, want.tokenType, want.value, want.line, want.column)
+			}
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+		line    int
+		column  int
+	}{
+		{`"a \q"`, `Unknown escape sequence '\q'.`, 1, 4},
+		{"\"first\n  second \\x\"", `Unknown escape sequence '\x'.`, 2, 10},
+		{`"\u{D800}"`, "Invalid Unicode code point.", 1, 2},
+		{`"\u{}"`, "Invalid Unicode escape sequence.", 1, 2},
+		{`"\u41"`, `Expect '{' after '\u'.`, 1, 2},
+		{`x = "abc`, "Unterminated string.", 1, 5},
+		{`x = "a ${b`, "Unterminated string.", 1, 5},
+		{"`raw", "Unterminated string.", 1, 1},
+	}
+
+	for _, test := range tests {
+		diagnostics := errors.NewDiagnostics("")
+		_, _ = NewScanner(test.source, diagnostics).ScanTokens()
+		items := diagnostics.Items()
+		if len(items) != 1 {
+			t.Errorf("%s: %d errors, want 1", test.source, len(items))
+			continue
+		}
+		got := items[0]
+		if got.Message != test.message || got.Line != test.line || got.Column != test.column {
+			t.Errorf("%s: %q at %d:%d, want %q at %d:%d", test.source,
+				got.Message, got.Line, got.Column, test.message, test.line, test.column)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	source := "var café = \"日本語\"; π\n  ñ + 名前"
+	tokens, err := NewScanner(source, errors.NewDiagnostics("")).ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		lexeme string
+		line   int
+		column int
+	}{
+		{"var", 1, 0},
+		{"café", 1, 4},
+		{"=", 1, 9},
+		{`"日本語"`, 1, 11},
+		{";", 1, 16},
+		{"π", 1, 18},
+		{"ñ", 2, 2},
+		{"+", 2, 4},
+		{"名前", 2, 6},
+		{"", 2, 8},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("scanned %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, want := range want {
+		got := tokens[i]
+		if got.Lexeme != want.lexeme || got.Line != want.line || got.Column != want.column {
+			t.Errorf("token %d is %q at %d:%d, want %q at %d:%d", i,
+				got.Lexeme, got.Line, got.Column, want.lexeme, want.line, want.column)
+		}
+	}
+	if got := (*tokens[3].Literal).(string); got != "日本語" {
+		t.Errorf("string is %q", got)
+	}
+	if tokens[1].Type != token.Identifier {
+		t.Errorf("café scanned as %s", tokens[1].Type)
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	diagnostics := errors.NewDiagnostics("")
+	_, err := NewScanner("var é = \"a\xffb\";\n\xfe", diagnostics).ScanTokens()
+	if err == nil {
+		t.Fatal("no error")
+	}
+
+	want := []string{"1:11: error: Invalid UTF-8 encoding.", "2:1: error: Invalid UTF-8 encoding."}
+	items := diagnostics.Items()
+	if len(items) != len(want) {
+		t.Fatalf("%d errors, want %d", len(items), len(want))
+	}
+	for i, item := range items {
+		if got := item.String(); got != want[i] {
+			t.Errorf("error %d is %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		source string
+		want   any
+	}{
+		{"42", int64(42)},
+		{"0xFF", int64(255)},
+		{"0b1010", int64(10)},
+		{"0O17", int64(15)},
+		{"1_000_000", int64(1000000)},
+		{"9223372036854775807", int64(9223372036854775807)},
+		{"0x7fff_ffff_ffff_ffff", int64(9223372036854775807)},
+		{"1.5", 1.5},
+		{"1e9", 1e9},
+		{"2.5E-3", 2.5e-3},
+		{"1_0.2_5e+1", 102.5},
+	}
+
+	for _, test := range tests {
+		tokens, err := NewScanner(test.source, errors.NewDiagnostics("")).ScanTokens()
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if len(tokens) != 2 || tokens[0].Type != token.Number {
+			t.Errorf("%s: not scanned as one number", test.source)
+			continue
+		}
+		if got := *tokens[0].Literal; got != test.want {
+			t.Errorf("%s: scanned %T %v, want %T %v", test.source, got, got, test.want, test.want)
+		}
+	}
+}
+
+func TestNumberErrors(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+		column  int
+	}{
+		{"x = 0x;", "Expect digits in hexadecimal literal.", 5},
+		{"0b12", "Invalid digit '2' in binary literal.", 4},
+		{"0o7_9", "Invalid digit '9' in octal literal.", 5},
+		{"0xfg", "Invalid digit 'g' in hexadecimal literal.", 4},
+		{"1__000", "Digit separators must be between digits.", 2},
+		{"1_.5", "Digit separators must be between digits.", 2},
+		{"0x_1", "Digit separators must be between digits.", 3},
+		{"9223372036854775808", "Integer literal is too large.", 1},
+		{"1 ~ 2", "Unexpected character.", 3},
+	}
+
+	for _, test := range tests {
+		diagnostics := errors.NewDiagnostics("")
+		_, _ = NewScanner(test.source, diagnostics).ScanTokens()
+		items := diagnostics.Items()
+		if len(items) != 1 {
+			t.Errorf("%s: %d errors, want 1", test.source, len(items))
+			continue
+		}
+		got := items[0]
+		if got.Message != test.message || got.Column != test.column {
+			t.Errorf("%s: %q at column %d, want %q at column %d", test.source,
+				got.Message, got.Column, test.message, test.column)
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	tokens, err := NewScanner("a ** b * c % d ~/ e", errors.NewDiagnostics("")).ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []token.TokenType{
+		token.Identifier, token.StarStar, token.Identifier, token.Star, token.Identifier,
+		token.Percent, token.Identifier, token.TildeSlash, token.Identifier, token.EOF,
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("scanned %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, tokenType := range want {
+		if tokens[i].Type != tokenType {
+			t.Errorf("token %d is %s, want %s", i, tokens[i].Type, tokenType)
+		}
+	}
+}
+
+// BenchmarkLongLine scans minified code, all on one line.
+func BenchmarkLongLine(b *testing.B) {
+	source := strings.Repeat("var a = [1, \"é\", b.c(d)];", 10000)
+	for i := 0; i < b.N; i++ {
+		if _, err := NewScanner(source, errors.NewDiagnostics("")).ScanTokens(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package script
+
+import (
+	"fmt"
+	"interp/interpreter"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// toValue converts a Go value into a script value. Integers become int64,
+// unless they don't fit, floats become float64, slices become lists and maps
+// with string keys become maps.
+func toValue(value any) (any, error) {
+	switch value := value.(type) {
+	case nil, bool, string, int64, float64, interpreter.Callable, *interpreter.List, *interpreter.Map, *interpreter.Instance:
+		return value, nil
+	case []any:
+		elements := make([]any, len(value))
+		for i, element := range value {
+			converted, err := toValue(element)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = converted
+		}
+		return interpreter.NewList(elements), nil
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		result := interpreter.NewMap()
+		for _, key := range keys {
+			converted, err := toValue(value[key])
+			if err != nil {
+				return nil, err
+			}
+			_ = result.Set(key, converted)
+		}
+		return result, nil
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflected.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if reflected.Uint() > math.MaxInt64 {
+			return float64(reflected.Uint()), nil
+		}
+		return int64(reflected.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float(), nil
+	case reflect.Bool:
+		return reflected.Bool(), nil
+	case reflect.String:
+		return reflected.String(), nil
+	case reflect.Slice:
+		elements := make([]any, reflected.Len())
+		for i := range elements {
+			elements[i] = reflected.Index(i).Interface()
+		}
+		return toValue(elements)
+	case reflect.Map:
+		if reflected.Type().Key().Kind() != reflect.String {
+			break
+		}
+		elements := make(map[string]any, reflected.Len())
+		for iter := reflected.MapRange(); iter.Next(); {
+			elements[iter.Key().String()] = iter.Value().Interface()
+		}
+		return toValue(elements)
+	}
+
+	return nil, fmt.Errorf("can't convert %T to a script value", value)
+}
+
+// fromValue converts a script value into a Go value. Numbers are int64 or
+// float64, lists become []any and maps become map[any]any. Functions,
+// classes and instances are returned as is, so they can be passed back to
+// the script.
+func fromValue(value any) any {
+	switch value := value.(type) {
+	case *interpreter.List:
+		elements := make([]any, len(value.Elements()))
+		for i, element := range value.Elements() {
+			elements[i] = fromValue(element)
+		}
+		return elements
+	case *interpreter.Map:
+		result := make(map[any]any, len(value.Keys()))
+		for _, key := range value.Keys() {
+			element, _ := value.Get(key)
+			result[key] = fromValue(element)
+		}
+		return result
+	}
+	return value
+}
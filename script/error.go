@@ -0,0 +1,64 @@
+package script
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ErrorKind string
+
+const (
+	SyntaxError  ErrorKind = "syntax"
+	ResolveError ErrorKind = "resolve"
+	RuntimeError ErrorKind = "runtime"
+)
+
+// Error is an error found in a script. Lines and columns start at 1; they
+// are zero when the error has no position in the source, e.g. when a Go
+// function called by the script fails.
+type Error struct {
+	Kind    ErrorKind
+	Line    int
+	Column  int
+	Message string
+	// Stack is the call stack of a runtime error, innermost first. Its last
+	// frame is the top level of the script, or the function called by Call.
+	Stack []Frame
+}
+
+// Frame is a function that was running when a runtime error occurred, and
+// the position it had got to. File is empty for sources run with Run.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+	Column   int
+}
+
+func (e Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s error: %s", e.Kind, e.Message)
+	}
+	return fmt.Sprintf("[line %d] %s error: %s", e.Line, e.Kind, e.Message)
+}
+
+// Errors is the error returned by VM methods when a script doesn't compile:
+// the syntax or resolve errors found in it.
+type Errors []Error
+
+// Unwrap returns the errors, so that errors.As finds the first of them.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
@@ -0,0 +1,209 @@
+// Package script runs scripts from Go programs.
+//
+//	vm := script.New()
+//	vm.DefineFunction("greet", 1, func(args []any) (any, error) {
+//		return "Hello, " + args[0].(string), nil
+//	})
+//	err := vm.Run(`fun twice(x) { return x * 2; } print greet("world");`)
+//	result, err := vm.Call("twice", 21)
+package script
+
+import (
+	"fmt"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/parser"
+	"interp/resolver"
+	"interp/scanner"
+	"io"
+	"os"
+)
+
+// VM runs scripts. Globals defined by one script stay defined for the
+// scripts run after it.
+type VM struct {
+	interpreter *interpreter.Interpreter
+}
+
+func New() *VM {
+	inter := interpreter.NewInterpreter()
+	return &VM{interpreter: &inter}
+}
+
+// SetInput sets the reader that scripts read input from. It defaults to
+// standard input.
+func (vm *VM) SetInput(reader io.Reader) {
+	vm.interpreter.SetInput(reader)
+}
+
+// SetOutput sets the writer that scripts print to. It defaults to standard
+// output.
+func (vm *VM) SetOutput(writer io.Writer) {
+	vm.interpreter.SetOutput(writer)
+}
+
+// Define defines a global variable. The value is converted to a script value,
+// see Call for the supported types.
+func (vm *VM) Define(name string, value any) error {
+	converted, err := toValue(value)
+	if err != nil {
+		return err
+	}
+	vm.interpreter.Define(name, converted)
+	return nil
+}
+
+// DefineFunction defines a global function implemented in Go. The function
+// receives its arguments converted to Go values, and the value it returns is
+// converted back. An error it returns becomes a runtime error in the script.
+func (vm *VM) DefineFunction(name string, arity int, function func(args []any) (any, error)) {
+	native := interpreter.NewNative(name, arity, func(_ *interpreter.Interpreter, arguments []any) (any, error) {
+		args := make([]any, len(arguments))
+		for i, argument := range arguments {
+			args[i] = fromValue(argument)
+		}
+
+		result, err := function(args)
+		if err != nil {
+			return nil, interpreter.NewNativeError("%s", err.Error())
+		}
+
+		value, err := toValue(result)
+		if err != nil {
+			return nil, interpreter.NewNativeError("%s", err.Error())
+		}
+		return value, nil
+	})
+	vm.interpreter.Define(name, native)
+}
+
+// Get returns the value of a global variable converted to a Go value.
+func (vm *VM) Get(name string) (any, bool) {
+	value, ok := vm.interpreter.Global(name)
+	if !ok {
+		return nil, false
+	}
+	return fromValue(value), true
+}
+
+// Run runs the source. If it fails to compile, the returned error is of type
+// Errors, with every error found; if it fails while running, it is an Error.
+func (vm *VM) Run(source string) error {
+	return vm.run(source, "")
+}
+
+// RunFile runs the script in the file at path. Its imports are relative to
+// it, and the stack traces of its errors refer to it.
+func (vm *VM) RunFile(path string) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return vm.run(string(source), path)
+}
+
+func (vm *VM) run(source string, file string) error {
+	vm.interpreter.SetFile(file)
+	diagnostics := errors.NewDiagnostics(file)
+
+	tokens, _ := scanner.NewScanner(source, diagnostics).ScanTokens()
+	if diagnostics.HasErrors() {
+		return compileErrors(SyntaxError, diagnostics)
+	}
+
+	par := parser.NewParser(tokens, diagnostics)
+	statements, _ := par.Parse()
+	if diagnostics.HasErrors() {
+		return compileErrors(SyntaxError, diagnostics)
+	}
+
+	res := resolver.NewResolver(vm.interpreter, diagnostics)
+	if err := res.Resolve(statements); err != nil {
+		return err
+	}
+	if diagnostics.HasErrors() {
+		return compileErrors(ResolveError, diagnostics)
+	}
+
+	return runtimeError(vm.interpreter.Interpret(statements))
+}
+
+// Call calls the global function with the given name. The arguments are
+// converted to script values; numbers, strings, booleans, nil, slices and
+// maps with string keys are supported. The result is converted back to a Go
+// value: numbers are int64 or float64, lists []any and maps map[any]any. If
+// the call fails, the returned error is an Error.
+func (vm *VM) Call(name string, args ...any) (any, error) {
+	value, ok := vm.interpreter.Global(name)
+	if !ok {
+		return nil, Error{Kind: RuntimeError, Message: fmt.Sprintf("Undefined variable '%s'.", name)}
+	}
+	callee, ok := value.(interpreter.Callable)
+	if !ok {
+		return nil, Error{Kind: RuntimeError, Message: "Can only call functions and classes."}
+	}
+
+	arguments := make([]any, len(args))
+	for i, arg := range args {
+		argument, err := toValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		arguments[i] = argument
+	}
+
+	result, err := vm.interpreter.Call(callee, arguments)
+	if err != nil {
+		return nil, runtimeError(err)
+	}
+	return fromValue(result), nil
+}
+
+//goland:noinspection GoTypeAssertionOnErrors
+func runtimeError(err error) error {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case errors.RuntimeError:
+		return Error{
+			Kind:    RuntimeError,
+			Line:    err.Token().Line,
+			Column:  err.Token().Column + 1,
+			Message: err.Message(),
+			Stack:   stack(err.Stack()),
+		}
+	case interpreter.NativeError:
+		return Error{Kind: RuntimeError, Message: err.Error()}
+	}
+	return err
+}
+
+func stack(frames []errors.StackFrame) []Frame {
+	var result []Frame
+	for _, frame := range frames {
+		result = append(result, Frame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Token.Line,
+			Column:   frame.Token.Column + 1,
+		})
+	}
+	return result
+}
+
+// compileErrors converts the errors among the diagnostics. Warnings are
+// dropped.
+func compileErrors(kind ErrorKind, diagnostics *errors.Diagnostics) Errors {
+	var result Errors
+	for _, diagnostic := range diagnostics.Items() {
+		if diagnostic.Severity == errors.SeverityError {
+			result = append(result, Error{
+				Kind:    kind,
+				Line:    diagnostic.Line,
+				Column:  diagnostic.Column,
+				Message: diagnostic.Message,
+			})
+		}
+	}
+	return result
+}
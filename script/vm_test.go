@@ -0,0 +1,183 @@
+package script
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDefine(t *testing.T) {
+	vm := New()
+	var output bytes.Buffer
+	vm.SetOutput(&output)
+
+	values := map[string]any{
+		"count":  int32(3),
+		"ratio":  0.5,
+		"name":   "go",
+		"items":  []string{"a", "b"},
+		"config": map[string]any{"debug": true, "level": 2},
+	}
+	for name, value := range values {
+		if err := vm.Define(name, value); err != nil {
+			t.Fatalf("Define(%q): %v", name, err)
+		}
+	}
+	if err := vm.Define("channel", make(chan int)); err == nil {
+		t.Error("Define accepted a channel")
+	}
+	if err := vm.Define("byNumber", map[int]string{1: "one"}); err == nil {
+		t.Error("Define accepted a map with int keys")
+	}
+
+	vm.DefineFunction("greet", 1, func(args []any) (any, error) {
+		return "Hello, " + args[0].(string), nil
+	})
+
+	err := vm.Run(`
+print type(count);
+print type(ratio);
+print items;
+print config["level"] + 1;
+print greet(name);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "int\nfloat\n[\"a\", \"b\"]\n3\nHello, go\n"
+	if output.String() != want {
+		t.Errorf("printed %q, want %q", output.String(), want)
+	}
+}
+
+func TestGet(t *testing.T) {
+	vm := New()
+	err := vm.Run(`var big = 9007199254740993; var list = [1, 2.5, "three"]; var map = {"a": [nil]};`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want any
+	}{
+		{"big", int64(9007199254740993)},
+		{"list", []any{int64(1), 2.5, "three"}},
+		{"map", map[any]any{"a": []any{nil}}},
+	}
+	for _, test := range tests {
+		got, ok := vm.Get(test.name)
+		if !ok || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", test.name, got, ok, test.want)
+		}
+	}
+	if _, ok := vm.Get("missing"); ok {
+		t.Error("Get found an undefined variable")
+	}
+}
+
+type (
+	celsius float64
+	label   string
+	flag    bool
+)
+
+func TestCall(t *testing.T) {
+	vm := New()
+	err := vm.Run(`
+fun double(x) { return x * 2; }
+fun first(list) { return list[0]; }
+fun lookup(map, key) { return map[key]; }
+fun pair(a, b) { return [a, b]; }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []any
+		want any
+	}{
+		{"double", []any{21}, int64(42)},
+		{"double", []any{int64(1) << 62}, float64(1 << 63)},
+		{"double", []any{uint8(4)}, int64(8)},
+		{"double", []any{1.25}, 2.5},
+		{"first", []any{[]any{"x", "y"}}, "x"},
+		{"first", []any{[]int{7}}, int64(7)},
+		{"lookup", []any{map[string]any{"k": 1.5}, "k"}, 1.5},
+		{"lookup", []any{map[string]int{"k": 3}, "k"}, int64(3)},
+		{"lookup", []any{map[label][]string{"k": {"v"}}, "k"}, []any{"v"}},
+		{"double", []any{celsius(1.5)}, 3.0},
+		{"pair", []any{label("a"), flag(true)}, []any{"a", true}},
+		{"pair", []any{nil, true}, []any{nil, true}},
+	}
+	for _, test := range tests {
+		got, err := vm.Call(test.name, test.args...)
+		if err != nil {
+			t.Errorf("%s%v: %v", test.name, test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s%v = %#v, want %#v", test.name, test.args, got, test.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	vm := New()
+	vm.DefineFunction("fail", 0, func(args []any) (any, error) {
+		return nil, fmt.Errorf("Go failed.")
+	})
+
+	// Compile errors are all returned.
+	err := vm.Run("print 1 +;\nprint ;")
+	var compile Errors
+	if !errors.As(err, &compile) || len(compile) != 2 {
+		t.Fatalf("syntax errors are %#v", err)
+	}
+	if compile[0].Kind != SyntaxError || compile[0].Line != 1 || compile[0].Column != 10 {
+		t.Errorf("first syntax error is %+v", compile[0])
+	}
+	var first Error
+	if !errors.As(err, &first) || first.Line != 1 {
+		t.Errorf("errors.As found %+v", first)
+	}
+
+	err = vm.Run("{ var a = 1; var a = 2; print a; }")
+	if !errors.As(err, &compile) || compile[0].Kind != ResolveError {
+		t.Errorf("resolve error is %#v", err)
+	}
+
+	// A runtime error is returned alone, with its stack.
+	err = vm.Run("fun inner() {\n  return nil + 1;\n}\nfun outer() { inner(); }\nouter();")
+	var runtime Error
+	if !errors.As(err, &runtime) {
+		t.Fatalf("runtime error is %#v", err)
+	}
+	if runtime.Kind != RuntimeError || runtime.Line != 2 || runtime.Message != "Operands must be two numbers or two strings." {
+		t.Errorf("runtime error is %+v", runtime)
+	}
+	var functions []string
+	for _, frame := range runtime.Stack {
+		functions = append(functions, frame.Function)
+	}
+	if want := []string{"inner", "outer", "script"}; !reflect.DeepEqual(functions, want) {
+		t.Errorf("stack is %v, want %v", functions, want)
+	}
+
+	// Errors of Go functions are runtime errors at the call.
+	err = vm.Run("\nfail();")
+	if !errors.As(err, &runtime) || runtime.Line != 2 || runtime.Message != "Go failed." {
+		t.Errorf("Go function error is %#v", err)
+	}
+
+	if _, err := vm.Call("missing"); !errors.As(err, &runtime) || runtime.Message != "Undefined variable 'missing'." {
+		t.Errorf("calling an undefined function gave %#v", err)
+	}
+	if _, err := vm.Call("inner"); !errors.As(err, &runtime) || runtime.Line != 2 {
+		t.Errorf("failing call gave %#v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"interp/errors"
+	"interp/resolver"
+	"interp/tester"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// runTests runs the tests in test scripts, the files given and the _test.g
+// files in the directories given, or in the working directory.
+func runTests(arguments []string) int {
+	flags := flag.NewFlagSet("interp test", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+	run := flags.String("run", "", "run only the tests whose names match the regular expression")
+	asJSON := flags.Bool("json", false, "print the results as JSON")
+	asJUnit := flags.Bool("junit", false, "print the results as JUnit XML")
+	searchPath := flags.String("path", os.Getenv("INTERP_PATH"), "where imported modules are looked for")
+	if err := flags.Parse(arguments); err != nil {
+		return exitUsage
+	}
+	if *asJSON && *asJUnit {
+		flags.Usage()
+		return exitUsage
+	}
+
+	options := tester.Options{SearchPath: filepath.SplitList(*searchPath)}
+	if *run != "" {
+		filter, err := regexp.Compile(*run)
+		if err != nil {
+			fmt.Printf("interp test: invalid -run: %v\n", err)
+			return exitUsage
+		}
+		options.Filter = filter
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := scripts(paths, "_test.g")
+	if err != nil {
+		fmt.Println(err)
+		return exitIO
+	}
+
+	// Results are printed as they come, unless they are printed as a report
+	// at the end.
+	text := !*asJSON && !*asJUnit
+	status := exitOK
+	var suites []tester.Suite
+	for _, file := range files {
+		suite, fileStatus := testScript(file, options, text)
+		status = max(status, fileStatus)
+		suites = append(suites, suite)
+		if text {
+			printSuite(suite)
+		}
+	}
+
+	switch {
+	case *asJSON:
+		if suites == nil {
+			suites = []tester.Suite{}
+		}
+		err = tester.WriteJSON(os.Stdout, suites)
+	case *asJUnit:
+		err = tester.WriteJUnit(os.Stdout, suites)
+	case len(files) == 0:
+		fmt.Println("no test files")
+	}
+	if err != nil {
+		fmt.Println(err)
+		return exitIO
+	}
+	return status
+}
+
+// testScript runs the tests of a script, and returns the exit status for
+// them. Errors in the script are reported when the results are shown as
+// text.
+func testScript(file string, options tester.Options, text bool) (tester.Suite, int) {
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return tester.Suite{File: file, Error: err.Error(), Tests: []tester.Result{}}, exitIO
+	}
+	source := string(bytes)
+
+	diagnostics := errors.NewDiagnostics(file)
+	statements := parse(source, diagnostics)
+	status := exitSyntax
+	if !diagnostics.HasErrors() {
+		res := resolver.NewResolver(discardLocals{}, diagnostics)
+		if err := res.Resolve(statements); err != nil && text {
+			fmt.Println(err)
+		}
+		status = exitResolve
+	}
+	if text {
+		report(diagnostics, source, formatPretty)
+	}
+	if diagnostics.HasErrors() {
+		return tester.Suite{File: file, Error: tester.CompileError(diagnostics), Tests: []tester.Result{}}, status
+	}
+
+	suite := tester.Run(file, statements, options)
+	if suite.Failed() {
+		return suite, exitFailure
+	}
+	return suite, exitOK
+}
+
+// printSuite prints the failed tests of a script, with what they printed,
+// and a line for the script.
+func printSuite(suite tester.Suite) {
+	if suite.Error != "" {
+		fmt.Printf("FAIL\t%s\t[does not compile]\n", suite.File)
+		return
+	}
+
+	for _, test := range suite.Tests {
+		if test.Status == tester.Pass {
+			continue
+		}
+		fmt.Printf("--- FAIL: %s (%.2fs)\n", test.Name, test.Duration.Seconds())
+		message := test.Message
+		if test.Status == tester.Error {
+			message = "runtime error: " + message
+		}
+		fmt.Printf("    %s:%d: %s\n", suite.File, test.FailureLine, message)
+		for _, line := range strings.Split(strings.TrimSuffix(test.Output, "\n"), "\n") {
+			if line != "" {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	switch {
+	case suite.Failed():
+		fmt.Printf("FAIL\t%s\t%.3fs\n", suite.File, suite.Duration.Seconds())
+	case len(suite.Tests) == 0:
+		fmt.Printf("ok  \t%s\t%.3fs [no tests to run]\n", suite.File, suite.Duration.Seconds())
+	default:
+		fmt.Printf("ok  \t%s\t%.3fs\n", suite.File, suite.Duration.Seconds())
+	}
+}
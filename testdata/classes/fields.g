@@ -0,0 +1,9 @@
+class Point {}
+
+var point = Point();
+point.x = 1;
+point.y = 2;
+print point.x + point.y; // expect: 3
+
+point.x = 10;
+print point.x; // expect: 10
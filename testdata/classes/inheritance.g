@@ -0,0 +1,19 @@
+class Animal {
+  init(name) {
+    this.name = name;
+  }
+
+  speak() {
+    return this.name + " makes a sound";
+  }
+}
+
+class Dog < Animal {
+  speak() {
+    return super.speak() + ", woof";
+  }
+}
+
+var dog = Dog("Rex");
+print dog.speak(); // expect: Rex makes a sound, woof
+print dog.name; // expect: Rex
@@ -0,0 +1,13 @@
+class Foo {
+  init(arg) {
+    print "init " + arg;
+    this.arg = arg;
+  }
+}
+
+var foo = Foo("one"); // expect: init one
+
+// Calling init again runs it and returns the instance itself.
+var again = foo.init("two"); // expect: init two
+print again == foo; // expect: true
+print foo.arg; // expect: two
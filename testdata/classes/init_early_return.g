@@ -0,0 +1,11 @@
+class Foo {
+  init() {
+    this.done = true;
+    return; // expect warning: Unreachable code after return.
+    this.done = false;
+  }
+}
+
+var foo = Foo();
+print foo.done; // expect: true
+print foo.init() == foo; // expect: true
@@ -0,0 +1,10 @@
+class Greeter {
+  greet(name) {
+    return "Hello, " + name + "!";
+  }
+}
+
+var greeter = Greeter();
+print greeter.greet("world"); // expect: Hello, world!
+print greeter; // expect: Greeter instance
+print Greeter; // expect: Greeter
@@ -0,0 +1,17 @@
+class Box {
+  init(value) {
+    this.value = value;
+  }
+
+  getter() {
+    return fun () {
+      return this.value;
+    };
+  }
+}
+
+var get = Box("boxed").getter();
+print get(); // expect: boxed
+
+var method = Box("bound").getter;
+print method()(); // expect: bound
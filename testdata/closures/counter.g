@@ -0,0 +1,16 @@
+fun makeCounter() {
+  var count = 0;
+  fun increment() {
+    count = count + 1;
+    return count;
+  }
+  return increment;
+}
+
+var counter = makeCounter();
+print counter(); // expect: 1
+print counter(); // expect: 2
+
+var other = makeCounter();
+print other(); // expect: 1
+print counter(); // expect: 3
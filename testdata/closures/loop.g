@@ -0,0 +1,10 @@
+var functions = [];
+for (var i = 0; i < 3; i = i + 1) {
+  var j = i;
+  functions.push(fun () {
+    return j;
+  });
+}
+
+print functions[0](); // expect: 0
+print functions[2](); // expect: 2
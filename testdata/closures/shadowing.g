@@ -0,0 +1,11 @@
+var a = "global";
+{
+  fun showA() {
+    print a;
+  }
+
+  showA(); // expect: global
+  var a = "block";
+  showA(); // expect: global
+  print a; // expect: block
+}
@@ -0,0 +1,19 @@
+var get;
+var set;
+
+fun pair() {
+  var value = "before";
+  fun getter() {
+    return value;
+  }
+  fun setter(v) {
+    value = v;
+  }
+  get = getter;
+  set = setter;
+}
+
+pair();
+print get(); // expect: before
+set("after");
+print get(); // expect: after
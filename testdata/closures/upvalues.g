@@ -0,0 +1,53 @@
+// A closure reaches through several functions to the variable.
+fun outer() {
+  var x = "outer";
+  fun middle() {
+    fun inner() {
+      return x;
+    }
+    return inner;
+  }
+  return middle;
+}
+print outer()()(); // expect: outer
+
+// Parameters are captured like locals.
+fun adder(n) {
+  return fun (m) { return n + m; };
+}
+var addTwo = adder(2);
+print addTwo(3); // expect: 5
+
+// A variable stays alive after its block ends, and closures that capture it
+// see each other's assignments.
+var increment;
+var read;
+{
+  var count = 0;
+  increment = fun () { count = count + 1; };
+  read = fun () { return count; };
+}
+increment();
+increment();
+print read(); // expect: 2
+
+// Assigning after the closure was made is seen by it.
+fun late() {
+  var value = "first";
+  var get = fun () { return value; };
+  value = "second";
+  return get;
+}
+print late()(); // expect: second
+
+// Each call has its own variables.
+fun counter() {
+  var count = 0;
+  return fun () { count = count + 1; return count; };
+}
+var a = counter();
+var b = counter();
+a();
+a();
+print a(); // expect: 3
+print b(); // expect: 1
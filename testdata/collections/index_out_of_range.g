@@ -0,0 +1,3 @@
+var list = [1, 2, 3];
+print list[2]; // expect: 3
+print list[3]; // expect runtime error: Index out of range.
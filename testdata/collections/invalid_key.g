@@ -0,0 +1,2 @@
+var map = {};
+map[[]] = 1; // expect runtime error: Map keys must be strings, numbers, booleans or nil.
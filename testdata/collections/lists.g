@@ -0,0 +1,23 @@
+var list = [1, "two", [3]];
+print list; // expect: [1, "two", [3]]
+print list[0]; // expect: 1
+print list[-1][0]; // expect: 3
+
+list[1] = 2;
+list.push(4);
+print list.len(); // expect: 4
+print list.pop(); // expect: 4
+list.insert(0, 0);
+print list; // expect: [0, 1, 2, [3]]
+print list.slice(1, -1); // expect: [1, 2]
+print list.contains(2); // expect: true
+print list.contains(5); // expect: false
+print []; // expect: []
+
+// Lists are shared, not copied.
+var nested = [[1, 2], [3, 4]];
+var alias = nested;
+alias[1][0] = 30;
+alias.push(5);
+print nested; // expect: [[1, 2], [30, 4], 5]
+print len(nested); // expect: 3
@@ -0,0 +1,20 @@
+var map = {"a": 1, "b": 2};
+print map; // expect: {"a": 1, "b": 2}
+print map["a"]; // expect: 1
+
+// Keys stay in the order they were first set in.
+map["c"] = 3;
+map["a"] = 10;
+print map.keys(); // expect: ["a", "b", "c"]
+print map.values(); // expect: [10, 2, 3]
+
+print map.has("b"); // expect: true
+print map.delete("b"); // expect: 2
+print map.has("b"); // expect: false
+print map.len(); // expect: 2
+print {}; // expect: {}
+
+var keys = {nil: "nil", true: "true", 1: "one"};
+print keys[nil]; // expect: nil
+print keys[true]; // expect: true
+print keys[1]; // expect: one
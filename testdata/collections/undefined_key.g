@@ -0,0 +1,3 @@
+var map = {"a": 1};
+print map["a"]; // expect: 1
+print map["b"]; // expect runtime error: Undefined key.
@@ -0,0 +1,19 @@
+var i = 0;
+while (true) {
+  if (i == 3) break;
+  print i;
+  i = i + 1;
+}
+// expect: 0
+// expect: 1
+// expect: 2
+
+for (var j = 0; j < 10; j = j + 1) {
+  for (var k = 0; k < 10; k = k + 1) {
+    if (k == 1) break;
+    print j;
+  }
+  if (j == 1) break;
+}
+// expect: 0
+// expect: 1
@@ -0,0 +1,43 @@
+var i = 0;
+while (i < 5) {
+  i = i + 1;
+  if (i % 2 == 0) continue;
+  print i;
+}
+// expect: 1
+// expect: 3
+// expect: 5
+
+// The increment of a for loop still runs.
+for (var j = 0; j < 4; j = j + 1) {
+  if (j == 1) continue;
+  print j;
+}
+// expect: 0
+// expect: 2
+// expect: 3
+
+// Only the innermost loop continues.
+for (var outer = 0; outer < 2; outer = outer + 1) {
+  for (var inner = 0; inner < 3; inner = inner + 1) {
+    if (inner == 1) continue;
+    print "${outer} ${inner}";
+  }
+}
+// expect: 0 0
+// expect: 0 2
+// expect: 1 0
+// expect: 1 2
+
+// Locals declared in the body are discarded when it continues, and closures
+// over them keep their own copies.
+var closures = [];
+for (var k = 0; k < 3; k = k + 1) {
+  var captured = k * 10;
+  closures.push(fun () { return captured; });
+  if (k < 2) continue;
+  print "last";
+}
+// expect: last
+print closures[0](); // expect: 0
+print closures[2](); // expect: 20
@@ -0,0 +1,9 @@
+fun sign(n) {
+  if (n < 0) return "negative";
+  else if (n == 0) return "zero";
+  else return "positive";
+}
+
+print sign(-3); // expect: negative
+print sign(0); // expect: zero
+print sign(5); // expect: positive
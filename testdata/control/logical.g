@@ -0,0 +1,4 @@
+print nil or "default"; // expect: default
+print "first" or "second"; // expect: first
+print nil and "never"; // expect: nil
+print 1 and 2; // expect: 2
@@ -0,0 +1,38 @@
+// Finally runs however the try block is left.
+try {
+  print "body"; // expect: body
+} finally {
+  print "finally"; // expect: finally
+}
+
+try {
+  throw "error";
+} catch (e) {
+  print "catch"; // expect: catch
+} finally {
+  print "finally after catch"; // expect: finally after catch
+}
+
+// An error thrown in catch still runs finally before it goes on.
+try {
+  try {
+    throw 1;
+  } catch (e) {
+    throw 2;
+  } finally {
+    print "inner finally"; // expect: inner finally
+  }
+} catch (e) {
+  print "outer caught ${e}"; // expect: outer caught 2
+}
+
+// Without a catch, the error goes on after finally.
+try {
+  try {
+    throw "up";
+  } finally {
+    print "cleanup"; // expect: cleanup
+  }
+} catch (e) {
+  print e; // expect: up
+}
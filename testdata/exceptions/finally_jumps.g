@@ -0,0 +1,48 @@
+// Return, break and continue run the finally blocks they pass through.
+fun early() {
+  try {
+    return "returned";
+  } finally {
+    print "finally before return"; // expect: finally before return
+  }
+  return "not reached";
+}
+print early(); // expect: returned
+
+fun nested() {
+  try {
+    try {
+      return 1;
+    } finally {
+      print "inner"; // expect: inner
+    }
+  } finally {
+    print "outer"; // expect: outer
+  }
+}
+print nested(); // expect: 1
+
+for (var i = 0; i < 3; i = i + 1) {
+  try {
+    if (i == 0) continue;
+    if (i == 2) break;
+    print "body ${i}";
+  } finally {
+    print "finally ${i}";
+  }
+}
+// expect: finally 0
+// expect: body 1
+// expect: finally 1
+// expect: finally 2
+
+// The value returned is the one computed before finally runs.
+fun value() {
+  var x = "before";
+  try {
+    return x;
+  } finally {
+    x = "after";
+  }
+}
+print value(); // expect: before
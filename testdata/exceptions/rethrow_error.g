@@ -0,0 +1,15 @@
+// A caught runtime error that is thrown again is reported where it happened.
+fun run() {
+  try {
+    fail();
+  } catch (e) {
+    print "cleaning up"; // expect: cleaning up
+    throw e;
+  }
+}
+
+fun fail() {
+  [].pop(); // expect runtime error: Can't pop from an empty list.
+}
+
+run();
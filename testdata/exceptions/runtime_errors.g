@@ -0,0 +1,30 @@
+// Runtime errors are caught as error values.
+try {
+  print nil + 1;
+} catch (e) {
+  print type(e); // expect: error
+  print e.message; // expect: Operands must be two numbers or two strings.
+  print e.line; // expect: 3
+}
+
+fun divide(a, b) {
+  return a / b;
+}
+try {
+  divide(1, 0);
+} catch (e) {
+  print e.message; // expect: Can not divide by zero.
+  print e.stack; // expect: ["divide (testdata/exceptions/runtime_errors.g:11:12)", "script (testdata/exceptions/runtime_errors.g:14:14)"]
+}
+
+// Errors of natives and undefined variables are caught too.
+try {
+  num("abc");
+} catch (e) {
+  print e.message; // expect: Can't convert 'abc' to a number.
+}
+try {
+  print missing;
+} catch (e) {
+  print e.message; // expect: Undefined variable 'missing'.
+}
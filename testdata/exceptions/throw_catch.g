@@ -0,0 +1,51 @@
+// Any value can be thrown, and the catch clause binds it.
+try {
+  print "before"; // expect: before
+  throw "boom";
+  print "not reached";
+} catch (e) {
+  print "caught ${e}"; // expect: caught boom
+}
+
+try {
+  throw [1, 2];
+} catch (e) {
+  print e[1]; // expect: 2
+}
+
+// Values thrown in called functions unwind to the nearest try.
+fun fail(value) {
+  throw value;
+}
+fun middle() {
+  fail(42);
+  print "not reached";
+}
+try {
+  middle();
+} catch (e) {
+  print e + 1; // expect: 43
+}
+
+// An inner try that catches stops the value.
+try {
+  try {
+    throw "inner";
+  } catch (e) {
+    print "inner caught ${e}"; // expect: inner caught inner
+  }
+  print "after inner"; // expect: after inner
+} catch (e) {
+  print "not reached";
+}
+
+// A catch clause can throw again, to the enclosing try.
+try {
+  try {
+    throw 1;
+  } catch (e) {
+    throw e + 1;
+  }
+} catch (e) {
+  print "rethrown ${e}"; // expect: rethrown 2
+}
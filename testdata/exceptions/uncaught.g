@@ -0,0 +1,10 @@
+try {
+  print "no catch"; // expect: no catch
+} finally {
+  print "done"; // expect: done
+}
+
+fun fail() {
+  throw "nobody catches this"; // expect runtime error: Uncaught exception: nobody catches this
+}
+fail();
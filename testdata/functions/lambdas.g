@@ -0,0 +1,16 @@
+fun apply(f, x) {
+  return f(x);
+}
+
+print apply(fun (x) {
+  return x * 2;
+}, 21); // expect: 42
+
+var add = fun (a, b) {
+  return a + b;
+};
+print add(1, 2); // expect: 3
+
+print fun () {
+  return "immediate";
+}(); // expect: immediate
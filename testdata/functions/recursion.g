@@ -0,0 +1,6 @@
+fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 1) + fib(n - 2);
+}
+
+print fib(10); // expect: 55
@@ -0,0 +1,9 @@
+fun nothing() {
+}
+
+fun early() {
+  return;
+}
+
+print nothing(); // expect: nil
+print early(); // expect: nil
@@ -0,0 +1,12 @@
+import "lib/shapes.g" as shapes; // expect: loading shapes
+
+print shapes; // expect: <module lib/shapes.g>
+print shapes.describe("square"); // expect: square has 4 sides
+print shapes.version; // expect: 1.0
+var counter = shapes.Counter();
+counter.next();
+print counter.next(); // expect: 2
+
+// A module runs once, however many times it is imported.
+import "lib/shapes.g" as again;
+print again.version; // expect: 1.0
@@ -0,0 +1,2 @@
+print "once"; // expect: once
+import "lib/import_entry.g" as back; // expect runtime error: Circular import of module "../import_entry.g".
@@ -0,0 +1,4 @@
+import {describe, Counter,} from "lib/shapes.g"; // expect: loading shapes
+
+print describe("triangle"); // expect: triangle has 3 sides
+print Counter().next(); // expect: 1
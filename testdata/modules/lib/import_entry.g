@@ -0,0 +1,2 @@
+// Imports the script that imports it, which is already running.
+import "../import_entry.g" as entry;
@@ -0,0 +1,24 @@
+// A module with exported and private names.
+print "loading shapes";
+
+var sides = {"triangle": 3, "square": 4};
+
+export fun describe(shape) {
+  return "${shape} has ${sides[shape]} sides";
+}
+
+export class Counter {
+  init() {
+    this.count = 0;
+  }
+  next() {
+    this.count = this.count + 1;
+    return this.count;
+  }
+}
+
+export var version = "1.0";
+
+fun helper() {
+  return "private";
+}
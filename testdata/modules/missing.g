@@ -0,0 +1 @@
+import "lib/missing.g" as missing; // expect runtime error: Can't find module "lib/missing.g".
@@ -0,0 +1,2 @@
+import "lib/shapes.g" as shapes; // expect: loading shapes
+print shapes.helper(); // expect runtime error: Module "lib/shapes.g" doesn't export 'helper'.
@@ -0,0 +1,4 @@
+import { // expect: loading shapes
+  describe,
+  sides // expect runtime error: Module "lib/shapes.g" doesn't export 'sides'.
+} from "lib/shapes.g";
@@ -0,0 +1,2 @@
+print 10 ~/ 3; // expect: 3
+print 10 % 0; // expect runtime error: Can not divide by zero.
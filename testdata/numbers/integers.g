@@ -0,0 +1,16 @@
+// Integers are exact, where floats would round.
+print 9007199254740993; // expect: 9007199254740993
+print 9007199254740993 + 2; // expect: 9007199254740995
+print 9223372036854775807; // expect: 9223372036854775807
+
+// Results that don't fit are floats.
+print 9223372036854775807 + 1; // expect: 9223372036854776000
+print 2 ** 64; // expect: 18446744073709552000
+
+print 0.1 + 0.2; // expect: 0.30000000000000004
+print 1 == 1.0; // expect: true
+print type(1); // expect: int
+print type(1.5); // expect: float
+print type(6 / 3); // expect: int
+print type(7 / 2); // expect: float
+print {2: "two"}[2.0]; // expect: two
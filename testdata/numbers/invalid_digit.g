@@ -0,0 +1 @@
+print 0b102; // expect error: Invalid digit '2' in binary literal.
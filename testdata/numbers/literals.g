@@ -0,0 +1,10 @@
+print 0xFF; // expect: 255
+print 0XdeadBEEF; // expect: 3735928559
+print 0b1010; // expect: 10
+print 0o17; // expect: 15
+print 1_000_000; // expect: 1000000
+print 0xff_ff; // expect: 65535
+print 1e9; // expect: 1000000000
+print 2.5E-3; // expect: 0.0025
+print 1_0.2_5e+1; // expect: 102.5
+print 1e21; // expect: 1e+21
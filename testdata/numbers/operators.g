@@ -0,0 +1,14 @@
+print 7 / 2; // expect: 3.5
+print 7 ~/ 2; // expect: 3
+print -7 ~/ 2; // expect: -4
+print 7.5 ~/ 2; // expect: 3
+print 7 % 3; // expect: 1
+print -7 % 3; // expect: 2
+print 7 % -3; // expect: -2
+print 7.5 % 2; // expect: 1.5
+print 2 ** 10; // expect: 1024
+print 2 ** -1; // expect: 0.5
+print 2 ** 3 ** 2; // expect: 512
+print -2 ** 2; // expect: -4
+print (-2) ** 2; // expect: 4
+print 1 + 2 * 3 % 4; // expect: 3
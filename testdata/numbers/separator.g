@@ -0,0 +1 @@
+print 1__000; // expect error: Digit separators must be between digits.
@@ -0,0 +1 @@
+print 9223372036854775808; // expect error: Integer literal is too large.
@@ -0,0 +1 @@
+break; // expect error: Can't break outside loop
@@ -0,0 +1 @@
+class Self < Self {} // expect error: A class can't inherit from itself.
@@ -0,0 +1,4 @@
+var a = "outer";
+{
+  var a = a; // expect error: Can't read local variable in its own initializer.
+}
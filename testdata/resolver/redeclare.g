@@ -0,0 +1,5 @@
+fun f() {
+  var a = 1;
+  var a = 2; // expect error: Already a variable with this name in this scope.
+  print a;
+}
@@ -0,0 +1 @@
+return 1; // expect error: Can't return from top-level code.
@@ -0,0 +1,5 @@
+class Foo {
+  init() {
+    return 1; // expect error: Can't return a value from an initializer.
+  }
+}
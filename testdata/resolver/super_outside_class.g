@@ -0,0 +1,3 @@
+fun f() {
+  super.method(); // expect error: Can't use 'super' outside of a class.
+}
@@ -0,0 +1,5 @@
+class Base {
+  method() {
+    super.method(); // expect error: Can't use 'super' in a class with no superclass.
+  }
+}
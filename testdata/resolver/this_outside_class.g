@@ -0,0 +1 @@
+print this; // expect error: Can't use 'this' outside of a class.
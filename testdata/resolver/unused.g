@@ -0,0 +1,4 @@
+{
+  var unused = 1; // expect warning: Variable 'unused' is declared but never used.
+}
+print "still runs"; // expect: still runs
@@ -0,0 +1,2 @@
+var notAFunction = "string";
+notAFunction(); // expect runtime error: Can only call functions and classes.
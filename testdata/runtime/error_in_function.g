@@ -0,0 +1,11 @@
+// The error is reported where it happens, not where the outermost call is.
+fun outer() {
+  return inner();
+}
+
+fun inner() {
+  print "inner"; // expect: inner
+  return -"one"; // expect runtime error: Operand must be a number.
+}
+
+outer();
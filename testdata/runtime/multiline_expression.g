@@ -0,0 +1,4 @@
+// The error is on the line of the operator, not where the expression starts.
+var total = 1 +
+  2 * // expect runtime error: Operands must be numbers.
+  nil;
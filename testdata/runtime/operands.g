@@ -0,0 +1 @@
+print 1 - "one"; // expect runtime error: Operands must be numbers.
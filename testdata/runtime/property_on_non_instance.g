@@ -0,0 +1,2 @@
+var number = 1;
+print number.field; // expect runtime error: Only instances have properties.
@@ -0,0 +1,4 @@
+fun recurse(n) {
+  return recurse(n + 1); // expect runtime error: Stack overflow.
+}
+recurse(0);
@@ -0,0 +1,2 @@
+print "before"; // expect: before
+print missing; // expect runtime error: Undefined variable 'missing'.
@@ -0,0 +1,3 @@
+print "a ${} b"; // expect error: Expect expression.
+print "a ${} b ${1} c"; // expect error: Expect expression.
+print "${1 +}x"; // expect error: Expect expression.
@@ -0,0 +1,7 @@
+print "a\tb"; // expect: a	b
+print "say \"hi\""; // expect: say "hi"
+print "back\\slash"; // expect: back\slash
+print "\u{48}\u{69}"; // expect: Hi
+print "\u{2603}"; // expect: ☃
+print "not \${interpolated}"; // expect: not ${interpolated}
+print len("\n"); // expect: 1
@@ -0,0 +1,20 @@
+var name = "world";
+print "Hello ${name}!"; // expect: Hello world!
+print "${1 + 2} is three"; // expect: 3 is three
+print "${nil} ${true} ${[1, "a"]}"; // expect: nil true [1, "a"]
+print "outer ${"inner ${name}"}"; // expect: outer inner world
+print "a ${"}"} b"; // expect: a } b
+
+class Point {
+  init(x, y) {
+    this.x = x;
+    this.y = y;
+  }
+
+  show() {
+    return "(${this.x}, ${this.y})";
+  }
+}
+
+print Point(1, 2).show(); // expect: (1, 2)
+print "map ${ {"k": "v"}["k"] }"; // expect: map v
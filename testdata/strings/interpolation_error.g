@@ -0,0 +1,2 @@
+var point = nil;
+print "x is ${point.x}"; // expect runtime error: Only instances have properties.
@@ -0,0 +1 @@
+print "value ${1 2}"; // expect error: Expect '}' after interpolated expression.
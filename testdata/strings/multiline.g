@@ -0,0 +1,6 @@
+var text = "first
+second";
+print text;
+// expect: first
+// expect: second
+print len(split(text, "\n")); // expect: 2
@@ -0,0 +1,6 @@
+print `C:\path\no ${escapes}`; // expect: C:\path\no ${escapes}
+var raw = `two
+lines`;
+print raw;
+// expect: two
+// expect: lines
@@ -0,0 +1,2 @@
+print "fine";
+print "bad \q escape"; // expect error: Unknown escape sequence '\q'.
@@ -0,0 +1 @@
+print "value ${1 + 2 "; // expect error: Unterminated string.
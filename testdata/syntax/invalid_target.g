@@ -0,0 +1,2 @@
+var a = 1;
+a + 1 = 2; // expect error: Invalid assigment target.
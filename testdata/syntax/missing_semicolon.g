@@ -0,0 +1 @@
+print 1 // expect error: Expect ';' after value.
@@ -0,0 +1,20 @@
+var café = "naïve";
+var 名前 = "日本語";
+var ñandú = 2;
+print café; // expect: naïve
+print 名前; // expect: 日本語
+print ñandú * 2; // expect: 4
+
+fun größe(wert) {
+  return len(wert);
+}
+
+print größe(名前); // expect: 3
+
+class Übung {
+  prüfen() {
+    return "geprüft";
+  }
+}
+
+print Übung().prüfen(); // expect: geprüft
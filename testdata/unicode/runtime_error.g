@@ -0,0 +1,2 @@
+var 名前 = "日本";
+print 名前 - 1; // expect runtime error: Operands must be numbers.
@@ -0,0 +1,5 @@
+var snow = "☃ and 😀";
+print snow; // expect: ☃ and 😀
+print len(snow); // expect: 7
+print substr(snow, 0, 1); // expect: ☃
+print upper("ärger"); // expect: ÄRGER
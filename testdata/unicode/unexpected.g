@@ -0,0 +1 @@
+var price = 1 € 2; // expect error: Unexpected character.
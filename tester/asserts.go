@@ -0,0 +1,115 @@
+package tester
+
+import (
+	"fmt"
+	"interp/interpreter"
+	"interp/token"
+	"strconv"
+)
+
+// failure is a failed assertion. It ends the test wherever it happens, even
+// inside a try statement, and is reported at the assertion's call.
+type failure struct {
+	message string
+	paren   token.Token
+	located bool
+}
+
+func fail(format string, a ...any) failure {
+	return failure{message: fmt.Sprintf(format, a...)}
+}
+
+func (f failure) Error() string {
+	return f.message
+}
+
+// At keeps the innermost call, the assertion itself.
+func (f failure) At(paren token.Token) error {
+	if !f.located {
+		f.paren = paren
+		f.located = true
+	}
+	return f
+}
+
+func natives() map[string]interpreter.Callable {
+	return map[string]interpreter.Callable{
+		"assert":       interpreter.NewNative("assert", 1, nativeAssert),
+		"assertEqual":  interpreter.NewNative("assertEqual", 2, nativeAssertEqual),
+		"assertThrows": interpreter.NewNative("assertThrows", 1, nativeAssertThrows),
+	}
+}
+
+func nativeAssert(interpreter *interpreter.Interpreter, arguments []any) (any, error) {
+	if !interpreter.Truthy(arguments[0]) {
+		return nil, fail("Assertion failed.")
+	}
+	return nil, nil
+}
+
+func nativeAssertEqual(interpreter *interpreter.Interpreter, arguments []any) (any, error) {
+	actual, expected := arguments[0], arguments[1]
+	if !equal(actual, expected) {
+		return nil, fail("Expected %s but got %s.", show(interpreter, expected), show(interpreter, actual))
+	}
+	return nil, nil
+}
+
+// nativeAssertThrows calls the function and returns the error it threw, as
+// a catch clause would get it.
+func nativeAssertThrows(inter *interpreter.Interpreter, arguments []any) (any, error) {
+	function, ok := arguments[0].(interpreter.Callable)
+	if !ok || function.Arity() != 0 {
+		return nil, interpreter.NewNativeError("assertThrows expects a function without parameters.")
+	}
+
+	caught, threw, err := inter.Try(function, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !threw {
+		return nil, fail("Expected the function to throw.")
+	}
+	return caught, nil
+}
+
+// equal compares lists and maps by their elements, and other values as the
+// == operator does.
+func equal(a any, b any) bool {
+	switch a := a.(type) {
+	case *interpreter.List:
+		b, ok := b.(*interpreter.List)
+		if !ok || len(a.Elements()) != len(b.Elements()) {
+			return false
+		}
+		for index, element := range a.Elements() {
+			if !equal(element, b.Elements()[index]) {
+				return false
+			}
+		}
+		return true
+	case *interpreter.Map:
+		b, ok := b.(*interpreter.Map)
+		if !ok || len(a.Keys()) != len(b.Keys()) {
+			return false
+		}
+		for _, key := range a.Keys() {
+			aValue, _ := a.Get(key)
+			bValue, ok := b.Get(key)
+			if !ok || !equal(aValue, bValue) {
+				return false
+			}
+		}
+		return true
+	}
+	return interpreter.Equal(a, b)
+}
+
+// show returns how a value is shown in a failure message. Strings are
+// quoted so that they can be told apart from other values.
+func show(interpreter *interpreter.Interpreter, value any) string {
+	if text, ok := value.(string); ok {
+		return strconv.Quote(text)
+	}
+	return interpreter.Stringify(value)
+}
@@ -0,0 +1,126 @@
+package tester
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MarshalJSON gives the duration in seconds.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		Duration float64 `json:"duration"`
+	}{result(r), r.Duration.Seconds()})
+}
+
+// MarshalJSON gives the duration in seconds.
+func (s Suite) MarshalJSON() ([]byte, error) {
+	type suite Suite
+	return json.Marshal(struct {
+		suite
+		Duration float64 `json:"duration"`
+	}{suite(s), s.Duration.Seconds()})
+}
+
+// WriteJSON writes the suites as a JSON array.
+func WriteJSON(w io.Writer, suites []Suite) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(suites)
+}
+
+// The JUnit XML format, as CI servers read it.
+type (
+	junitSuites struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Errors   int          `xml:"errors,attr"`
+		Time     string       `xml:"time,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Errors   int         `xml:"errors,attr"`
+		Time     string      `xml:"time,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitProblem `xml:"failure,omitempty"`
+		Error     *junitProblem `xml:"error,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+	junitProblem struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// WriteJUnit writes the suites as JUnit XML, a test suite for each script. A
+// script that doesn't compile is a suite with one test case in error.
+func WriteJUnit(w io.Writer, suites []Suite) error {
+	var report junitSuites
+	var total time.Duration
+	for _, suite := range suites {
+		junit := junitSuite{Name: suite.File, Time: seconds(suite.Duration)}
+		if suite.Error != "" {
+			junit.Cases = append(junit.Cases, junitCase{
+				Name:      suite.File,
+				Classname: suite.File,
+				Time:      seconds(0),
+				Error:     &junitProblem{Message: suite.Error, Text: suite.File + ":" + suite.Error},
+			})
+			junit.Errors++
+		}
+		for _, test := range suite.Tests {
+			junitTest := junitCase{
+				Name:      test.Name,
+				Classname: suite.File,
+				Time:      seconds(test.Duration),
+				SystemOut: test.Output,
+			}
+			problem := &junitProblem{Message: test.Message, Text: fmt.Sprintf("%s:%d: %s", suite.File, test.FailureLine, test.Message)}
+			switch test.Status {
+			case Fail:
+				junitTest.Failure = problem
+				junit.Failures++
+			case Error:
+				junitTest.Error = problem
+				junit.Errors++
+			}
+			junit.Cases = append(junit.Cases, junitTest)
+		}
+		junit.Tests = len(junit.Cases)
+
+		report.Suites = append(report.Suites, junit)
+		report.Tests += junit.Tests
+		report.Failures += junit.Failures
+		report.Errors += junit.Errors
+		total += suite.Duration
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
@@ -0,0 +1,181 @@
+// Package tester runs the tests in test scripts. A test is a top-level
+// function whose name starts with "test". Each runs in a fresh interpreter,
+// after the top-level code of its script, with the assertion natives
+// defined:
+//
+//	assert(condition)
+//	assertEqual(actual, expected)
+//	assertThrows(function)
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"interp/ast"
+	"interp/errors"
+	"interp/interpreter"
+	"interp/resolver"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a test.
+type Status string
+
+const (
+	Pass Status = "pass"
+	// Fail is a test whose assertion failed.
+	Fail Status = "fail"
+	// Error is a test that stopped with a runtime error.
+	Error Status = "error"
+)
+
+// Result is the outcome of a test and why it failed.
+type Result struct {
+	Name string `json:"name"`
+	// Line is where the test function is declared.
+	Line   int    `json:"line"`
+	Status Status `json:"status"`
+	// FailureLine is the line of the failed assertion or of the runtime
+	// error, and Message what went wrong.
+	FailureLine int           `json:"failureLine,omitempty"`
+	Message     string        `json:"message,omitempty"`
+	Output      string        `json:"output,omitempty"`
+	Duration    time.Duration `json:"-"`
+}
+
+// Suite is the outcome of the tests of a script. Error is set instead when
+// the script doesn't compile.
+type Suite struct {
+	File     string        `json:"file"`
+	Error    string        `json:"error,omitempty"`
+	Tests    []Result      `json:"tests"`
+	Duration time.Duration `json:"-"`
+}
+
+// Failed reports whether any test failed, or the script didn't compile.
+func (s Suite) Failed() bool {
+	if s.Error != "" {
+		return true
+	}
+	for _, test := range s.Tests {
+		if test.Status != Pass {
+			return true
+		}
+	}
+	return false
+}
+
+// Options select the tests to run and how.
+type Options struct {
+	// Filter selects the tests by name. Nil runs them all.
+	Filter *regexp.Regexp
+	// SearchPath is where imported modules are looked for.
+	SearchPath []string
+}
+
+// Run runs the tests of a script that has been parsed and resolved without
+// errors.
+func Run(file string, statements []ast.Stmt, options Options) Suite {
+	// Every test has its own interpreter, and the variables resolved for one
+	// are recorded to be given to the others.
+	var locals recordedLocals
+	res := resolver.NewResolver(&locals, errors.NewDiagnostics(file))
+	_ = res.Resolve(statements)
+
+	suite := Suite{File: file, Tests: []Result{}}
+	start := time.Now()
+	for _, statement := range statements {
+		if export, ok := statement.(*ast.ExportStmt); ok {
+			statement = export.Declaration
+		}
+		function, ok := statement.(*ast.FunctionStmt)
+		if !ok || !strings.HasPrefix(function.Name.Lexeme, "test") {
+			continue
+		}
+		if options.Filter != nil && !options.Filter.MatchString(function.Name.Lexeme) {
+			continue
+		}
+		suite.Tests = append(suite.Tests, runTest(file, statements, locals, function, options))
+	}
+	suite.Duration = time.Since(start)
+	return suite
+}
+
+// runTest runs the script's top-level code and then the test function.
+func runTest(file string, statements []ast.Stmt, locals recordedLocals, function *ast.FunctionStmt, options Options) Result {
+	result := Result{Name: function.Name.Lexeme, Line: function.Name.Line, Status: Pass}
+	if len(function.Params) != 0 {
+		result.Status = Fail
+		result.FailureLine = function.Name.Line
+		result.Message = "Test functions can't have parameters."
+		return result
+	}
+
+	var output bytes.Buffer
+	inter := interpreter.NewInterpreter()
+	inter.SetFile(file)
+	inter.SetOutput(&output)
+	inter.SetSearchPath(options.SearchPath)
+	for name, native := range natives() {
+		inter.Define(name, native)
+	}
+	locals.replay(&inter)
+
+	start := time.Now()
+	err := inter.Interpret(statements)
+	if err == nil {
+		test, _ := inter.Global(function.Name.Lexeme)
+		_, err = inter.Call(test.(interpreter.Callable), nil)
+	}
+	result.Duration = time.Since(start)
+	result.Output = output.String()
+
+	//goland:noinspection GoTypeAssertionOnErrors
+	switch err := err.(type) {
+	case nil:
+	case failure:
+		result.Status = Fail
+		result.FailureLine = err.paren.Line
+		result.Message = err.message
+	case errors.RuntimeError:
+		result.Status = Error
+		result.FailureLine = err.Token().Line
+		result.Message = err.Message()
+	default:
+		result.Status = Error
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// recordedLocals records the variables resolved in a script.
+type recordedLocals []local
+
+type local struct {
+	expr  ast.Expr
+	depth int
+	slot  int
+}
+
+func (r *recordedLocals) Resolve(expr ast.Expr, depth int, slot int) {
+	*r = append(*r, local{expr, depth, slot})
+}
+
+func (r recordedLocals) replay(locals resolver.Locals) {
+	for _, local := range r {
+		locals.Resolve(local.expr, local.depth, local.slot)
+	}
+}
+
+// CompileError returns the message that a suite reports for a script that
+// doesn't compile: its first error.
+func CompileError(diagnostics *errors.Diagnostics) string {
+	for _, diagnostic := range diagnostics.Items() {
+		if diagnostic.Severity == errors.SeverityError {
+			return fmt.Sprintf("%d:%d: %s", diagnostic.Line, diagnostic.Column, diagnostic.Message)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package tester
+
+import (
+	"interp/errors"
+	"interp/parser"
+	"interp/scanner"
+	"regexp"
+	"testing"
+)
+
+const script = `var calls = [];
+
+fun testPasses() {
+  calls.push(1);
+  assertEqual(calls, [1]);
+  assertEqual({"a": [1, 2]}, {"a": [1, 2]});
+}
+
+fun testIsolated() {
+  calls.push(2);
+  assertEqual(len(calls), 1);
+}
+
+fun testFails() {
+  try {
+    assertEqual(1 + 1, 3);
+  } catch (e) {}
+}
+
+fun testHelperFails() {
+  check(false);
+}
+
+fun check(value) {
+  assert(value);
+}
+
+fun testThrows() {
+  var e = assertThrows(fun () { throw "boom"; });
+  assertEqual(e, "boom");
+  assertThrows(fun () {});
+}
+
+fun testRuntimeError() {
+  print "before";
+  return nil.field;
+}
+
+export fun testExported() {
+  assertEqual(calls, []);
+}
+`
+
+func TestRun(t *testing.T) {
+	diagnostics := errors.NewDiagnostics("")
+	tokens, _ := scanner.NewScanner(script, diagnostics).ScanTokens()
+	par := parser.NewParser(tokens, diagnostics)
+	statements, err := par.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Result{
+		{Name: "testPasses", Line: 3, Status: Pass},
+		{Name: "testIsolated", Line: 9, Status: Pass},
+		{Name: "testFails", Line: 14, Status: Fail, FailureLine: 16, Message: "Expected 3 but got 2."},
+		{Name: "testHelperFails", Line: 20, Status: Fail, FailureLine: 25, Message: "Assertion failed."},
+		{Name: "testThrows", Line: 28, Status: Fail, FailureLine: 31, Message: "Expected the function to throw."},
+		{Name: "testRuntimeError", Line: 34, Status: Error, FailureLine: 36, Message: "Only instances have properties.", Output: "before\n"},
+		{Name: "testExported", Line: 39, Status: Pass},
+	}
+	suite := Run("math_test.g", statements, Options{})
+	if len(suite.Tests) != len(want) {
+		t.Fatalf("ran %d tests, want %d", len(suite.Tests), len(want))
+	}
+	for i, got := range suite.Tests {
+		got.Duration = 0
+		if got != want[i] {
+			t.Errorf("got %+v\nwant %+v", got, want[i])
+		}
+	}
+	if !suite.Failed() {
+		t.Error("the suite didn't fail")
+	}
+
+	suite = Run("math_test.g", statements, Options{Filter: regexp.MustCompile("^testP")})
+	if len(suite.Tests) != 1 || suite.Tests[0].Name != "testPasses" || suite.Failed() {
+		t.Errorf("filtered run gave %+v", suite.Tests)
+	}
+}
@@ -1,21 +1,28 @@
 package token
 
 var Keywords = map[string]TokenType{
-	"and":    And,
-	"break":  Break,
-	"class":  Class,
-	"else":   Else,
-	"false":  False,
-	"for":    For,
-	"fun":    Fun,
-	"if":     If,
-	"nil":    Nil,
-	"or":     Or,
-	"print":  Print,
-	"return": Return,
-	"super":  Super,
-	"this":   This,
-	"true":   True,
-	"var":    Var,
-	"while":  While,
+	"and":      And,
+	"break":    Break,
+	"catch":    Catch,
+	"class":    Class,
+	"continue": Continue,
+	"else":     Else,
+	"export":   Export,
+	"false":    False,
+	"finally":  Finally,
+	"for":      For,
+	"fun":      Fun,
+	"if":       If,
+	"import":   Import,
+	"nil":      Nil,
+	"or":       Or,
+	"print":    Print,
+	"return":   Return,
+	"super":    Super,
+	"this":     This,
+	"throw":    Throw,
+	"true":     True,
+	"try":      Try,
+	"var":      Var,
+	"while":    While,
 }
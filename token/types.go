@@ -4,17 +4,21 @@ type TokenType string
 
 const (
 	// Single-character token
-	LeftParen  TokenType = "left_paren"
-	RightParen TokenType = "right_paren"
-	LeftBrace  TokenType = "left_brace"
-	RightBrace TokenType = "right_brace"
-	Comma      TokenType = "comma"
-	Semicolon  TokenType = "semicolon"
-	Dot        TokenType = "dot"
-	Plus       TokenType = "plus"
-	Minus      TokenType = "minus"
-	Star       TokenType = "star"
-	Slash      TokenType = "slash"
+	LeftParen    TokenType = "left_paren"
+	RightParen   TokenType = "right_paren"
+	LeftBrace    TokenType = "left_brace"
+	RightBrace   TokenType = "right_brace"
+	LeftBracket  TokenType = "left_bracket"
+	RightBracket TokenType = "right_bracket"
+	Comma        TokenType = "comma"
+	Colon        TokenType = "colon"
+	Semicolon    TokenType = "semicolon"
+	Dot          TokenType = "dot"
+	Plus         TokenType = "plus"
+	Minus        TokenType = "minus"
+	Star         TokenType = "star"
+	Slash        TokenType = "slash"
+	Percent      TokenType = "percent"
 
 	// One or two character token
 	Equal        TokenType = "equal"
@@ -29,30 +33,56 @@ const (
 	Pipe         TokenType = "pipe"
 	AndAnd       TokenType = "and_and"
 	PipePipe     TokenType = "pipe_pipe"
+	StarStar     TokenType = "star_star"
+	// TildeSlash is integer division, which rounds down. Two slashes start a
+	// comment.
+	TildeSlash TokenType = "tilde_slash"
 
 	// Literals
 	String TokenType = "string"
 	Number TokenType = "number"
+	// Interpolation is the part of a string before an interpolated
+	// expression, up to and including its "${". The expressions of a string
+	// are joined to its parts by binary expressions with this operator,
+	// which stringifies both operands.
+	Interpolation TokenType = "interpolation"
+	// InterpolationMiddle is the part of a string between two interpolated
+	// expressions, from the "}" of the first to the "${" of the second.
+	InterpolationMiddle TokenType = "interpolation_middle"
+	// InterpolationEnd is the part of a string after its last interpolated
+	// expression, from its "}" to the closing quote.
+	InterpolationEnd TokenType = "interpolation_end"
 
 	Identifier TokenType = "identifier"
 
 	// Keywords
-	If     TokenType = "if"
-	Else   TokenType = "else"
-	True   TokenType = "true"
-	False  TokenType = "false"
-	Nil    TokenType = "nil"
-	Class  TokenType = "class"
-	Fun    TokenType = "fun"
-	Var    TokenType = "var"
-	For    TokenType = "for"
-	While  TokenType = "while"
-	Print  TokenType = "print"
-	Return TokenType = "return"
-	Or     TokenType = "or"
-	Super  TokenType = "super"
-	This   TokenType = "this"
-	Break  TokenType = "break"
+	If       TokenType = "if"
+	Else     TokenType = "else"
+	True     TokenType = "true"
+	False    TokenType = "false"
+	Nil      TokenType = "nil"
+	Class    TokenType = "class"
+	Fun      TokenType = "fun"
+	Var      TokenType = "var"
+	For      TokenType = "for"
+	While    TokenType = "while"
+	Print    TokenType = "print"
+	Return   TokenType = "return"
+	Or       TokenType = "or"
+	Super    TokenType = "super"
+	This     TokenType = "this"
+	Break    TokenType = "break"
+	Continue TokenType = "continue"
+	Throw    TokenType = "throw"
+	Try      TokenType = "try"
+	Catch    TokenType = "catch"
+	Finally  TokenType = "finally"
+	Import   TokenType = "import"
+	Export   TokenType = "export"
+
+	// Comments aren't among the tokens the scanner returns; it keeps them
+	// apart for tools that print source back, such as the formatter.
+	Comment TokenType = "comment"
 
 	EOF TokenType = "eof"
 )
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"interp/ast"
+	"interp/errors"
+	"io"
+	"os"
+)
+
+// printTree prints the syntax tree of a script, as S-expressions or as
+// JSON. The script is read from stdin when its name is "-".
+func printTree(arguments []string) int {
+	flags := flag.NewFlagSet("interp ast", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	if err := flags.Parse(arguments); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	file := flags.Arg(0)
+	var (
+		bytes []byte
+		err   error
+	)
+	if file == "-" {
+		file = fileStdin
+		bytes, err = io.ReadAll(os.Stdin)
+	} else {
+		bytes, err = os.ReadFile(file)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return exitIO
+	}
+	source := string(bytes)
+
+	diagnostics := errors.NewDiagnostics(file)
+	statements := parse(source, diagnostics)
+	if diagnostics.HasErrors() {
+		report(diagnostics, source, formatPretty)
+		return exitSyntax
+	}
+
+	if !*asJSON {
+		fmt.Print(ast.Sexpr(statements))
+		return exitOK
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(ast.Nodes(statements)); err != nil {
+		fmt.Println(err)
+		return exitIO
+	}
+	return exitOK
+}